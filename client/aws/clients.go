@@ -4,13 +4,20 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/aws/aws-sdk-go/service/elb"
 	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
 	"github.com/aws/aws-sdk-go/service/servicequotas"
 	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
 	"github.com/aws/aws-sdk-go/service/sts"
@@ -27,52 +34,107 @@ const (
 )
 
 type Config struct {
+	// AccountID is the ID of the account accessed by the clients. Clients of
+	// the same account share its rate limits regardless of the role or
+	// credentials used. Empty means rate limits are shared by clients using
+	// the same credentials only.
+	AccountID       string
 	AccessKeyID     string
 	AccessKeySecret string
-	Region          string
-	RoleARN         string
-	SessionToken    string
+	// CredentialSource is one of CredentialSourceDefault and
+	// CredentialSourceStatic. Empty means CredentialSourceStatic.
+	CredentialSource string
+	// ExternalID is passed when assuming RoleARN, if set.
+	ExternalID   string
+	Region       string
+	RoleARN      string
+	SessionToken string
+
+	// MaxRetries is the maximum number of retries of a single AWS API call.
+	// Zero means defaultMaxRetries.
+	MaxRetries int
+	// RateLimit is the number of AWS API calls per second allowed per account
+	// and service. Zero means no rate limiting.
+	RateLimit float64
+	// RateLimitBurst is the number of AWS API calls per account and service
+	// allowed to exceed RateLimit at once. It must be positive when RateLimit
+	// is set.
+	RateLimitBurst int
 }
 
 type Clients struct {
-	AutoScaling    *autoscaling.AutoScaling
-	CloudFormation *cloudformation.CloudFormation
+	AutoScaling    autoscalingiface.AutoScalingAPI
+	CloudFormation cloudformationiface.CloudFormationAPI
+	CloudWatch     cloudwatchiface.CloudWatchAPI
 	EC2            ec2iface.EC2API
 	ELB            elbiface.ELBAPI
+	ELBv2          elbv2iface.ELBV2API
 	ServiceQuotas  servicequotasiface.ServiceQuotasAPI
 	STS            stsiface.STSAPI
 	Support        supportiface.SupportAPI
 }
 
 func NewClients(config Config) (Clients, error) {
-	if config.AccessKeyID == "" {
-		return Clients{}, microerror.Maskf(invalidConfigError, "%T.AccessKeyID must not be empty", config)
-	}
-	if config.AccessKeySecret == "" {
-		return Clients{}, microerror.Maskf(invalidConfigError, "%T.AccessKeySecret must not be empty", config)
+	if config.CredentialSource == CredentialSourceStatic || config.CredentialSource == "" {
+		if config.AccessKeyID == "" {
+			return Clients{}, microerror.Maskf(invalidConfigError, "%T.AccessKeyID must not be empty", config)
+		}
+		if config.AccessKeySecret == "" {
+			return Clients{}, microerror.Maskf(invalidConfigError, "%T.AccessKeySecret must not be empty", config)
+		}
 	}
 	if config.Region == "" {
 		return Clients{}, microerror.Maskf(invalidConfigError, "%T.Region must not be empty", config)
 	}
 
+	if config.MaxRetries < 0 {
+		return Clients{}, microerror.Maskf(invalidConfigError, "%T.MaxRetries must not be negative", config)
+	}
+	if config.RateLimit < 0 {
+		return Clients{}, microerror.Maskf(invalidConfigError, "%T.RateLimit must not be negative", config)
+	}
+	if config.RateLimit > 0 && config.RateLimitBurst <= 0 {
+		return Clients{}, microerror.Maskf(invalidConfigError, "%T.RateLimitBurst must be positive", config)
+	}
+
 	var err error
 
+	var creds *credentials.Credentials
+	{
+		creds, err = newCredentials(config)
+		if err != nil {
+			return Clients{}, microerror.Mask(err)
+		}
+	}
+
 	var s *session.Session
 	{
 		c := &aws.Config{
-			Credentials: credentials.NewStaticCredentials(config.AccessKeyID, config.AccessKeySecret, config.SessionToken),
+			Credentials: creds,
 			Region:      aws.String(config.Region),
 		}
+		c = request.WithRetryer(c, newRetryer(config.MaxRetries))
 
+		// In case no credentials are given, the session resolves them using
+		// the default credential chain, which includes web identity tokens
+		// configured via environment variables as done for IRSA.
 		s, err = session.NewSession(c)
 		if err != nil {
 			return Clients{}, microerror.Mask(err)
 		}
+
+		if config.RateLimit > 0 {
+			s.Handlers.Sign.PushFrontNamed(newLimiterHandler(limiterIdentity(config), config.RateLimit, config.RateLimitBurst))
+		}
 	}
 
 	var c Clients
 	if config.RoleARN != "" {
-		creds := stscreds.NewCredentials(s, config.RoleARN)
+		creds := stscreds.NewCredentials(s, config.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+			if config.ExternalID != "" {
+				p.ExternalID = aws.String(config.ExternalID)
+			}
+		})
 		c = newClients(s, &aws.Config{Credentials: creds})
 	} else {
 		c = newClients(s)
@@ -81,14 +143,22 @@ func NewClients(config Config) (Clients, error) {
 	return c, nil
 }
 
-func newClients(session *session.Session, configs ...*aws.Config) Clients {
+func newClients(s *session.Session, configs ...*aws.Config) Clients {
 	supportConfigs := append(configs, aws.NewConfig().WithRegion(trustedAdvisorRegion))
 
+	// All service clients inherit the handlers of the session they are
+	// created from, which makes every AWS API call observable.
+	session := s.Copy()
+	session.Handlers.Complete.PushBackNamed(metricsHandler)
+	session.Handlers.Retry.PushBackNamed(throttlesHandler)
+
 	c := Clients{
 		AutoScaling:    autoscaling.New(session, configs...),
 		CloudFormation: cloudformation.New(session, configs...),
+		CloudWatch:     cloudwatch.New(session, configs...),
 		EC2:            ec2.New(session, configs...),
 		ELB:            elb.New(session, configs...),
+		ELBv2:          elbv2.New(session, configs...),
 		ServiceQuotas:  servicequotas.New(session, configs...),
 		STS:            sts.New(session, configs...),
 		Support:        support.New(session, supportConfigs...),
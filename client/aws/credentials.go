@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/endpointcreds"
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// CredentialSourceDefault uses the default credential chain of the AWS
+	// SDK. It covers environment variables, web identity token files as used
+	// by IRSA, ECS container credentials, EKS Pod Identity and EC2 instance
+	// profiles.
+	CredentialSourceDefault = "default"
+	// CredentialSourceStatic uses the access key given in the Config.
+	CredentialSourceStatic = "static"
+)
+
+const (
+	// podIdentityEndpointEnvVar and podIdentityTokenFileEnvVar are injected by
+	// the EKS Pod Identity webhook.
+	podIdentityEndpointEnvVar  = "AWS_CONTAINER_CREDENTIALS_FULL_URI"
+	podIdentityTokenFileEnvVar = "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"
+
+	credentialsExpiryWindow = 5 * time.Minute
+)
+
+// newCredentials returns the credentials for the given source. Nil
+// credentials are returned in case the session should resolve them using the
+// default credential chain of the AWS SDK.
+func newCredentials(config Config) (*credentials.Credentials, error) {
+	switch config.CredentialSource {
+	case CredentialSourceDefault:
+		endpoint := os.Getenv(podIdentityEndpointEnvVar)
+		tokenFile := os.Getenv(podIdentityTokenFileEnvVar)
+		if endpoint != "" && tokenFile != "" {
+			return credentials.NewCredentials(newPodIdentityProvider(endpoint, tokenFile)), nil
+		}
+
+		return nil, nil
+	case CredentialSourceStatic, "":
+		return credentials.NewStaticCredentials(config.AccessKeyID, config.AccessKeySecret, config.SessionToken), nil
+	}
+
+	return nil, microerror.Maskf(invalidConfigError, "%T.CredentialSource must be one of %#q or %#q", config, CredentialSourceDefault, CredentialSourceStatic)
+}
+
+// podIdentityProvider retrieves credentials from the EKS Pod Identity agent.
+// The default credential chain of the SDK version in use only accepts
+// loopback container credential endpoints and a static authorization token,
+// while the agent listens on a link-local address and rotates the token file.
+type podIdentityProvider struct {
+	endpoint  string
+	tokenFile string
+
+	mutex    sync.Mutex
+	provider credentials.Provider
+}
+
+func newPodIdentityProvider(endpoint string, tokenFile string) *podIdentityProvider {
+	p := &podIdentityProvider{
+		endpoint:  endpoint,
+		tokenFile: tokenFile,
+	}
+
+	return p
+}
+
+// Retrieve reads the current authorization token and requests new
+// credentials from the agent.
+func (p *podIdentityProvider) Retrieve() (credentials.Value, error) {
+	token, err := os.ReadFile(p.tokenFile)
+	if err != nil {
+		return credentials.Value{}, microerror.Mask(err)
+	}
+
+	d := defaults.Get()
+	provider := endpointcreds.NewProviderClient(*d.Config, d.Handlers, p.endpoint, func(ep *endpointcreds.Provider) {
+		ep.AuthorizationToken = strings.TrimSpace(string(token))
+		ep.ExpiryWindow = credentialsExpiryWindow
+	})
+
+	v, err := provider.Retrieve()
+	if err != nil {
+		return credentials.Value{}, microerror.Mask(err)
+	}
+
+	p.mutex.Lock()
+	p.provider = provider
+	p.mutex.Unlock()
+
+	return v, nil
+}
+
+// IsExpired returns whether the last retrieved credentials expired.
+func (p *podIdentityProvider) IsExpired() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.provider == nil || p.provider.IsExpired()
+}
+
+var _ credentials.Provider = &podIdentityProvider{}
@@ -0,0 +1,137 @@
+package aws
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNewCredentials(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config Config
+		env    map[string]string
+
+		expectedNil          bool
+		expectedErrorMatcher func(error) bool
+	}{
+		{
+			name: "case 0: static credentials",
+			config: Config{
+				AccessKeyID:      "id",
+				AccessKeySecret:  "secret",
+				CredentialSource: CredentialSourceStatic,
+			},
+		},
+		{
+			name: "case 1: empty source defaults to static credentials",
+			config: Config{
+				AccessKeyID:     "id",
+				AccessKeySecret: "secret",
+			},
+		},
+		{
+			name: "case 2: default chain is resolved by the session",
+			config: Config{
+				CredentialSource: CredentialSourceDefault,
+			},
+
+			expectedNil: true,
+		},
+		{
+			name: "case 3: pod identity",
+			config: Config{
+				CredentialSource: CredentialSourceDefault,
+			},
+			env: map[string]string{
+				podIdentityEndpointEnvVar:  "http://169.254.170.23/v1/credentials",
+				podIdentityTokenFileEnvVar: "/var/run/secrets/pods.eks.amazonaws.com/serviceaccount/eks-pod-identity-token",
+			},
+		},
+		{
+			name: "case 4: unknown source",
+			config: Config{
+				CredentialSource: "vault",
+			},
+
+			expectedErrorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			t.Setenv(podIdentityEndpointEnvVar, "")
+			t.Setenv(podIdentityTokenFileEnvVar, "")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			creds, err := newCredentials(tc.config)
+
+			switch {
+			case err == nil && tc.expectedErrorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.expectedErrorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.expectedErrorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.expectedErrorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if tc.expectedErrorMatcher != nil {
+				return
+			}
+			if (creds == nil) != tc.expectedNil {
+				t.Fatalf("expected nil credentials to be %t, got %#v", tc.expectedNil, creds)
+			}
+		})
+	}
+}
+
+func TestPodIdentityProvider(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "rotated-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		fmt.Fprintf(w, `{"AccessKeyId":"id","SecretAccessKey":"secret","Token":"token","Expiration":%q}`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	p := newPodIdentityProvider(server.URL, tokenFile)
+	if !p.IsExpired() {
+		t.Fatal("expected credentials to be expired before the first retrieval")
+	}
+
+	// The token is read on every retrieval as the agent rotates it.
+	for _, token := range []string{"initial-token", "rotated-token\n"} {
+		err := os.WriteFile(tokenFile, []byte(token), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = p.Retrieve()
+		if token == "initial-token" {
+			if err == nil {
+				t.Fatal("expected error for invalid token")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if p.IsExpired() {
+		t.Fatal("expected credentials to not be expired")
+	}
+}
@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultRateLimit is the default number of AWS API calls per second
+	// allowed per account and service.
+	DefaultRateLimit = 10
+	// DefaultRateLimitBurst is the default number of AWS API calls allowed to
+	// exceed the rate limit at once per account and service.
+	DefaultRateLimitBurst = 20
+)
+
+// limiters holds the token buckets of all accounts and services. Clients are
+// recreated whenever accounts are resolved again, so the buckets must outlive
+// them in order to actually bound the rate of API calls over time.
+var limiters = &limiterRegistry{
+	limiters: map[limiterKey]*rate.Limiter{},
+}
+
+type limiterKey struct {
+	identity string
+	service  string
+}
+
+type limiterRegistry struct {
+	limiters map[limiterKey]*rate.Limiter
+	mutex    sync.Mutex
+}
+
+// get returns the token bucket for the given identity and service, creating it
+// if necessary. Limits of existing buckets are updated to the given ones.
+func (r *limiterRegistry) get(identity string, service string, limit rate.Limit, burst int) *rate.Limiter {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	k := limiterKey{identity: identity, service: service}
+
+	l, ok := r.limiters[k]
+	if !ok {
+		l = rate.NewLimiter(limit, burst)
+		r.limiters[k] = l
+		return l
+	}
+
+	if l.Limit() != limit {
+		l.SetLimit(limit)
+	}
+	if l.Burst() != burst {
+		l.SetBurst(burst)
+	}
+
+	return l
+}
+
+// limiterIdentity returns the identity of the account whose API budget is
+// used by clients created with the given config. Several roles may access the
+// same account, so the account ID is preferred. Without it, the role or the
+// credentials are used, as they are bound to a single account.
+func limiterIdentity(config Config) string {
+	if config.AccountID != "" {
+		return config.AccountID
+	}
+	if config.RoleARN != "" {
+		return config.RoleARN
+	}
+	if config.AccessKeyID != "" {
+		return config.AccessKeyID
+	}
+
+	return config.CredentialSource
+}
+
+// newLimiterHandler returns a handler which blocks every attempt of an AWS API
+// call until the token bucket of the given identity and the called service
+// allows it. The identity is what distinguishes accounts, e.g. the account
+// ID.
+func newLimiterHandler(identity string, limit float64, burst int) request.NamedHandler {
+	h := request.NamedHandler{
+		Name: "aws-collector.limiter",
+		Fn: func(r *request.Request) {
+			l := limiters.get(identity, r.ClientInfo.ServiceName, rate.Limit(limit), burst)
+
+			err := l.Wait(r.Context())
+			if err != nil {
+				r.Error = awserr.New(request.CanceledErrorCode, "waiting for rate limiter failed", err)
+				r.Retryable = aws.Bool(false)
+			}
+		},
+	}
+
+	return h
+}
@@ -0,0 +1,60 @@
+package aws
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestLimiterIdentity(t *testing.T) {
+	testCases := []struct {
+		name   string
+		config Config
+
+		expectedIdentity string
+	}{
+		{
+			name: "case 0: roles of the same account share the account ID",
+			config: Config{
+				AccountID: "123456789012",
+				RoleARN:   "arn:aws:iam::123456789012:role/a",
+			},
+
+			expectedIdentity: "123456789012",
+		},
+		{
+			name: "case 1: role ARN without account ID",
+			config: Config{
+				AccessKeyID: "key",
+				RoleARN:     "arn:aws:iam::123456789012:role/a",
+			},
+
+			expectedIdentity: "arn:aws:iam::123456789012:role/a",
+		},
+		{
+			name: "case 2: access key without account ID",
+			config: Config{
+				AccessKeyID: "key",
+			},
+
+			expectedIdentity: "key",
+		},
+		{
+			name: "case 3: credential source without account ID",
+			config: Config{
+				CredentialSource: "Ec2InstanceMetadata",
+			},
+
+			expectedIdentity: "Ec2InstanceMetadata",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			identity := limiterIdentity(tc.config)
+
+			if identity != tc.expectedIdentity {
+				t.Fatalf("expected %#v, got %#v", tc.expectedIdentity, identity)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "aws_operator"
+	metricsSubsystem = "aws_api"
+)
+
+const (
+	labelCode      = "code"
+	labelOperation = "operation"
+	labelService   = "service"
+)
+
+var (
+	apiCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "calls_total",
+		Help:      "Number of AWS API calls made by the collectors.",
+	}, []string{
+		labelService,
+		labelOperation,
+	})
+	apiErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "errors_total",
+		Help:      "Number of failed AWS API calls made by the collectors.",
+	}, []string{
+		labelService,
+		labelOperation,
+		labelCode,
+	})
+	apiRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "retries_total",
+		Help:      "Number of retried AWS API call attempts made by the collectors.",
+	}, []string{
+		labelService,
+		labelOperation,
+	})
+	apiThrottles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "throttles_total",
+		Help:      "Number of AWS API call attempts made by the collectors which got throttled.",
+	}, []string{
+		labelService,
+		labelOperation,
+	})
+)
+
+// metricsHandler observes every completed AWS API request, including all of
+// its retries, so that it is counted exactly once.
+var metricsHandler = request.NamedHandler{
+	Name: "aws-collector.metrics",
+	Fn: func(r *request.Request) {
+		operation := operationName(r)
+
+		apiCalls.WithLabelValues(r.ClientInfo.ServiceName, operation).Inc()
+
+		if r.Error != nil {
+			code := "unknown"
+			if aerr, ok := r.Error.(awserr.Error); ok {
+				code = aerr.Code()
+			}
+
+			apiErrors.WithLabelValues(r.ClientInfo.ServiceName, operation, code).Inc()
+		}
+	},
+}
+
+// throttlesHandler observes every failed attempt of an AWS API request and
+// counts the ones which got throttled.
+var throttlesHandler = request.NamedHandler{
+	Name: "aws-collector.throttles",
+	Fn: func(r *request.Request) {
+		if r.IsErrorThrottle() {
+			apiThrottles.WithLabelValues(r.ClientInfo.ServiceName, operationName(r)).Inc()
+		}
+	},
+}
+
+// CollectMetrics emits the metrics about AWS API calls made by all clients.
+func CollectMetrics(ch chan<- prometheus.Metric) {
+	apiCalls.Collect(ch)
+	apiErrors.Collect(ch)
+	apiRetries.Collect(ch)
+	apiThrottles.Collect(ch)
+}
+
+// DescribeMetrics emits the description of the metrics about AWS API calls.
+func DescribeMetrics(ch chan<- *prometheus.Desc) {
+	apiCalls.Describe(ch)
+	apiErrors.Describe(ch)
+	apiRetries.Describe(ch)
+	apiThrottles.Describe(ch)
+}
+
+func operationName(r *request.Request) string {
+	if r.Operation == nil {
+		return ""
+	}
+
+	return r.Operation.Name
+}
@@ -0,0 +1,72 @@
+package aws
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const (
+	// defaultMaxRetries is the number of retries of a single AWS API call in
+	// case Config.MaxRetries is not set.
+	defaultMaxRetries = 5
+
+	minThrottleDelay = 1 * time.Second
+	maxThrottleDelay = 30 * time.Second
+)
+
+// retryer extends the default SDK retryer with an exponential backoff using
+// full jitter for throttled requests. Spreading retries over the whole backoff
+// window prevents collectors from hitting the shared API budget of an account
+// in lockstep, which would otherwise keep starving other consumers like
+// aws-operator.
+type retryer struct {
+	client.DefaultRetryer
+}
+
+func newRetryer(maxRetries int) retryer {
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+
+	r := retryer{
+		DefaultRetryer: client.DefaultRetryer{
+			NumMaxRetries:    maxRetries,
+			MinThrottleDelay: minThrottleDelay,
+			MaxThrottleDelay: maxThrottleDelay,
+		},
+	}
+
+	return r
+}
+
+// RetryRules returns the delay before the given request is retried.
+func (r retryer) RetryRules(req *request.Request) time.Duration {
+	apiRetries.WithLabelValues(req.ClientInfo.ServiceName, operationName(req)).Inc()
+
+	if !req.IsErrorThrottle() {
+		return r.DefaultRetryer.RetryRules(req)
+	}
+
+	return throttleDelay(req.RetryCount, r.MinThrottleDelay, r.MaxThrottleDelay, rand.Int63n)
+}
+
+// throttleDelay computes a random delay between min and the exponentially
+// growing upper bound for the given retry, which is capped by max. randFn
+// must return a random number in [0,n).
+func throttleDelay(retryCount int, min, max time.Duration, randFn func(n int64) int64) time.Duration {
+	upper := min
+	for i := 0; i <= retryCount && upper < max; i++ {
+		upper *= 2
+	}
+	if upper > max {
+		upper = max
+	}
+	if upper <= min {
+		return min
+	}
+
+	return min + time.Duration(randFn(int64(upper-min)))
+}
@@ -0,0 +1,63 @@
+package aws
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestThrottleDelay(t *testing.T) {
+	testCases := []struct {
+		name       string
+		retryCount int
+		randFn     func(n int64) int64
+
+		expectedDelay time.Duration
+	}{
+		{
+			name:       "case 0: first retry with lowest random value",
+			retryCount: 0,
+			randFn:     func(n int64) int64 { return 0 },
+
+			expectedDelay: time.Second,
+		},
+		{
+			name:       "case 1: first retry with highest random value",
+			retryCount: 0,
+			randFn:     func(n int64) int64 { return n - 1 },
+
+			expectedDelay: 2*time.Second - 1,
+		},
+		{
+			name:       "case 2: third retry with highest random value",
+			retryCount: 2,
+			randFn:     func(n int64) int64 { return n - 1 },
+
+			expectedDelay: 8*time.Second - 1,
+		},
+		{
+			name:       "case 3: upper bound capped by max",
+			retryCount: 10,
+			randFn:     func(n int64) int64 { return n - 1 },
+
+			expectedDelay: 30*time.Second - 1,
+		},
+		{
+			name:       "case 4: many retries do not overflow",
+			retryCount: 1000,
+			randFn:     func(n int64) int64 { return n / 2 },
+
+			expectedDelay: 15*time.Second + 500*time.Millisecond,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			delay := throttleDelay(tc.retryCount, minThrottleDelay, maxThrottleDelay, tc.randFn)
+
+			if delay != tc.expectedDelay {
+				t.Fatalf("expected %s, got %s", tc.expectedDelay, delay)
+			}
+		})
+	}
+}
@@ -2,11 +2,15 @@ package aws
 
 import (
 	"github.com/giantswarm/aws-collector/flag/service/aws/hostaccesskey"
+	"github.com/giantswarm/aws-collector/flag/service/aws/ratelimit"
 	"github.com/giantswarm/aws-collector/flag/service/aws/trustedadvisor"
 )
 
 type AWS struct {
-	HostAccessKey  hostaccesskey.HostAccessKey
-	Region         string
-	TrustedAdvisor trustedadvisor.TrustedAdvisor
+	CredentialSource string
+	HostAccessKey    hostaccesskey.HostAccessKey
+	MaxRetries       string
+	RateLimit        ratelimit.RateLimit
+	Region           string
+	TrustedAdvisor   trustedadvisor.TrustedAdvisor
 }
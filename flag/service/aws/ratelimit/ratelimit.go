@@ -0,0 +1,6 @@
+package ratelimit
+
+type RateLimit struct {
+	Burst             string
+	RequestsPerSecond string
+}
@@ -0,0 +1,6 @@
+package collection
+
+type Collection struct {
+	Background string
+	Interval   string
+}
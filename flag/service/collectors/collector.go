@@ -0,0 +1,33 @@
+package collectors
+
+type Collector struct {
+	Enabled  string
+	Interval string
+	Timeout  string
+}
+
+// CloudFormation is the configuration of the cloudformation collector.
+type CloudFormation struct {
+	Collector
+	DeletedStacksGracePeriod string
+	DriftDetection           DriftDetection
+}
+
+// DriftDetection is the configuration of the drift detection of the
+// cloudformation collector.
+type DriftDetection struct {
+	Enabled  string
+	Interval string
+}
+
+// NATGateway is the configuration of the natgateway collector.
+type NATGateway struct {
+	Collector
+	CloudWatchMetrics string
+}
+
+// ServiceQuota is the configuration of the servicequota collector.
+type ServiceQuota struct {
+	Collector
+	Quotas string
+}
@@ -0,0 +1,41 @@
+package collectors
+
+type Collectors struct {
+	ASG            Collector
+	CloudFormation CloudFormation
+	EBS            Collector
+	EC2Instances   Collector
+	EIP            Collector
+	ELB            Collector
+	ELBv2          Collector
+	NAT            Collector
+	NATGateway     NATGateway
+	Release        Collector
+	ServiceQuota   ServiceQuota
+	Subnet         Collector
+	TrustedAdvisor Collector
+	Update         Collector
+	VPC            Collector
+}
+
+// All returns the flags of all collectors keyed by the collector name used in
+// the configuration.
+func (c Collectors) All() map[string]Collector {
+	return map[string]Collector{
+		"asg":            c.ASG,
+		"cloudformation": c.CloudFormation.Collector,
+		"ebs":            c.EBS,
+		"ec2instances":   c.EC2Instances,
+		"eip":            c.EIP,
+		"elb":            c.ELB,
+		"elbv2":          c.ELBv2,
+		"nat":            c.NAT,
+		"natgateway":     c.NATGateway.Collector,
+		"release":        c.Release,
+		"servicequota":   c.ServiceQuota.Collector,
+		"subnet":         c.Subnet,
+		"trustedadvisor": c.TrustedAdvisor,
+		"update":         c.Update,
+		"vpc":            c.VPC,
+	}
+}
@@ -0,0 +1,10 @@
+package discovery
+
+type Discovery struct {
+	CAPA       Backend
+	GiantSwarm Backend
+}
+
+type Backend struct {
+	Enabled string
+}
@@ -4,11 +4,17 @@ import (
 	"github.com/giantswarm/operatorkit/v7/pkg/flag/service/kubernetes"
 
 	"github.com/giantswarm/aws-collector/flag/service/aws"
+	"github.com/giantswarm/aws-collector/flag/service/collection"
+	"github.com/giantswarm/aws-collector/flag/service/collectors"
+	"github.com/giantswarm/aws-collector/flag/service/discovery"
 	"github.com/giantswarm/aws-collector/flag/service/installation"
 )
 
 type Service struct {
 	AWS          aws.AWS
+	Collection   collection.Collection
+	Collectors   collectors.Collectors
+	Discovery    discovery.Discovery
 	Installation installation.Installation
 	Kubernetes   kubernetes.Kubernetes
 }
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/microkit/command"
@@ -9,10 +11,12 @@ import (
 	"github.com/giantswarm/micrologger"
 	"github.com/spf13/viper"
 
+	clientaws "github.com/giantswarm/aws-collector/client/aws"
 	"github.com/giantswarm/aws-collector/flag"
 	"github.com/giantswarm/aws-collector/pkg/project"
 	"github.com/giantswarm/aws-collector/server"
 	"github.com/giantswarm/aws-collector/service"
+	"github.com/giantswarm/aws-collector/service/collector"
 )
 
 var (
@@ -101,11 +105,32 @@ func mainE(ctx context.Context) error {
 
 	daemonCommand := newCommand.DaemonCommand().CobraCommand()
 
+	daemonCommand.PersistentFlags().String(f.Service.AWS.CredentialSource, clientaws.CredentialSourceStatic, fmt.Sprintf("Source of the credentials for the host cluster account. %#q uses the host access key, %#q uses the default AWS SDK credential chain, e.g. IRSA, EKS Pod Identity or instance profiles.", clientaws.CredentialSourceStatic, clientaws.CredentialSourceDefault))
 	daemonCommand.PersistentFlags().String(f.Service.AWS.HostAccessKey.ID, "", "ID of the AWS access key for the host cluster account. If empty, guest cluster account is used.")
 	daemonCommand.PersistentFlags().String(f.Service.AWS.HostAccessKey.Secret, "", "Secret of the AWS access key for the host cluster account. If empty, guest cluster account is used.")
 	daemonCommand.PersistentFlags().String(f.Service.AWS.HostAccessKey.Session, "", "Session token of the AWS access key for the host cluster account. If empty, guest cluster token is used.")
+	daemonCommand.PersistentFlags().Int(f.Service.AWS.MaxRetries, 0, "Maximum number of retries of a single AWS API call. Throttled calls are retried with jittered exponential backoff. If zero, 5 retries are done.")
+	daemonCommand.PersistentFlags().Int(f.Service.AWS.RateLimit.Burst, clientaws.DefaultRateLimitBurst, "Number of AWS API calls per account and service allowed to exceed the rate limit at once.")
+	daemonCommand.PersistentFlags().Float64(f.Service.AWS.RateLimit.RequestsPerSecond, clientaws.DefaultRateLimit, "Number of AWS API calls per second allowed per account and service. If zero, calls are not rate limited.")
 	daemonCommand.PersistentFlags().String(f.Service.AWS.Region, "", "Region for checking for orphaned AWS resources.")
-	daemonCommand.PersistentFlags().String(f.Service.AWS.TrustedAdvisor.Enabled, "", "Whether trusted advisor metrics collection is enabled.")
+	daemonCommand.PersistentFlags().String(f.Service.AWS.TrustedAdvisor.Enabled, "", "Whether trusted advisor metrics collection is enabled. Deprecated, use the trustedadvisor collector configuration instead.")
+
+	daemonCommand.PersistentFlags().Bool(f.Service.Collection.Background, false, "Whether collectors run in the background on their own interval, with scrapes being served from the last complete collection.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Collection.Interval, time.Minute, "Interval in which collectors run when background collection is enabled.")
+
+	for name, c := range f.Service.Collectors.All() {
+		daemonCommand.PersistentFlags().Bool(c.Enabled, c != f.Service.Collectors.TrustedAdvisor, fmt.Sprintf("Whether the %s collector is enabled.", name))
+		daemonCommand.PersistentFlags().Duration(c.Interval, 0, fmt.Sprintf("Interval in which the %s collector refreshes its metrics. If zero, the collector specific default is used.", name))
+		daemonCommand.PersistentFlags().Duration(c.Timeout, 0, fmt.Sprintf("Timeout for a single collection of the %s collector. If zero, no timeout is applied.", name))
+	}
+	daemonCommand.PersistentFlags().Duration(f.Service.Collectors.CloudFormation.DeletedStacksGracePeriod, 0, "Period for which the cloudformation collector still reports deleted stacks. If zero, deleted stacks are not reported.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Collectors.CloudFormation.DriftDetection.Enabled, false, "Whether the cloudformation collector triggers drift detection of the stacks it reports on.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Collectors.CloudFormation.DriftDetection.Interval, collector.DefaultDriftDetectionInterval, "Minimum age of the last drift detection of a stack before the cloudformation collector triggers a new one.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Collectors.NATGateway.CloudWatchMetrics, false, "Whether the natgateway collector reports the traffic metrics of NAT gateways from CloudWatch.")
+	daemonCommand.PersistentFlags().StringSlice(f.Service.Collectors.ServiceQuota.Quotas, collector.DefaultServiceQuotas, "Service quotas collected by the servicequota collector, given as <service code>/<quota code>.")
+
+	daemonCommand.PersistentFlags().Bool(f.Service.Discovery.CAPA.Enabled, true, "Whether Cluster API Provider AWS clusters are discovered for collecting metrics.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Discovery.GiantSwarm.Enabled, true, "Whether clusters based on infrastructure.giantswarm.io CRs are discovered for collecting metrics.")
 
 	daemonCommand.PersistentFlags().String(f.Service.Installation.Name, "", "Installation name for tagging AWS resources.")
 
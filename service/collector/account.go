@@ -0,0 +1,493 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+
+	clientaws "github.com/giantswarm/aws-collector/client/aws"
+	"github.com/giantswarm/aws-collector/service/internal/accountid"
+)
+
+const (
+	// defaultAccountRefreshInterval is the time resolved accounts are kept
+	// before credentials are read and account IDs are looked up again. It is
+	// chosen to be longer than a usual scrape interval so that all collectors
+	// of one collection cycle share a single resolution.
+	defaultAccountRefreshInterval = 5 * time.Minute
+
+	// accountIDIndexARN represents the index in which we can find the account
+	// ID in an IAM ARN, splitting by colon.
+	accountIDIndexARN = 4
+)
+
+const (
+	// subsystemAccount will become the second part of the metric name, right
+	// after namespace.
+	subsystemAccount   = "account"
+	subsystemCollector = "collector"
+)
+
+const (
+	// collectorAccountResolution is used as collector label value for errors
+	// happening while resolving the clients of a tenant cluster account.
+	collectorAccountResolution = "account_resolution"
+)
+
+// Reasons are used as label values of the account error metrics. They are
+// kept to a bounded set in order to not explode the metric cardinality.
+const (
+	reasonAccessDenied        = "access_denied"
+	reasonEndpointUnavailable = "endpoint_unavailable"
+	reasonOther               = "other"
+	reasonThrottling          = "throttling"
+)
+
+var (
+	accountResolutionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystemAccount,
+		Name:      "resolution_duration_seconds",
+		Help:      "Histogram for the duration of resolving the AWS clients of all accounts.",
+	})
+	accountResolutionFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystemAccount,
+		Name:      "resolution_failures_total",
+		Help:      "Number of failed attempts to resolve the AWS clients of all accounts.",
+	})
+	accountDiscoveryFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystemAccount,
+		Name:      "discovery_failures_total",
+		Help:      "Number of failed attempts to discover the clusters of a single discovery.",
+	}, []string{
+		labelDiscovery,
+	})
+	accountResolvedCount = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystemAccount,
+		Name:      "resolved_count",
+		Help:      "Gauge about the number of pairs of AWS account and region resolved for collection.",
+	})
+
+	accountErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystemCollector,
+		Name:      "account_errors_total",
+		Help:      "Number of errors collecting metrics for a single AWS account and region.",
+	}, []string{
+		labelCollector,
+		labelAccountID,
+		labelRegion,
+		labelReason,
+	})
+	accountUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystemCollector,
+		Name:      "account_up",
+		Help:      "Gauge indicating whether the last collection for an AWS account and region succeeded. 1 = succeeded, 0 = failed",
+	}, []string{
+		labelCollector,
+		labelAccountID,
+		labelRegion,
+	})
+)
+
+// account bundles the AWS clients of one account and region with the account
+// ID, so that collectors do not have to look up the account ID on their own.
+// An AWS account with clusters in several regions is represented by one
+// account per region.
+type account struct {
+	ID      string
+	Region  string
+	Clients clientaws.Clients
+}
+
+// role identifies how an account is accessed. The empty role represents the
+// account of the host credentials.
+type role struct {
+	ARN        string
+	ExternalID string
+}
+
+type accountKey struct {
+	ID     string
+	Region string
+}
+
+type accountRegistryConfig struct {
+	Discoveries []clusterDiscovery
+	Logger      micrologger.Logger
+
+	AWSConfig       clientaws.Config
+	RefreshInterval time.Duration
+}
+
+// accountRegistry resolves the AWS clients for every tenant cluster account
+// plus the host cluster account and shares them between all collectors. The
+// resolution requires reading credential secrets and calling STS for every
+// account, which is why its result is kept for the configured refresh
+// interval instead of being redone by every collector on every scrape.
+type accountRegistry struct {
+	discoveries []clusterDiscovery
+	logger      micrologger.Logger
+
+	awsConfig       clientaws.Config
+	refreshInterval time.Duration
+
+	accounts   []account
+	mutex      sync.Mutex
+	resolvedAt time.Time
+}
+
+func newAccountRegistry(config accountRegistryConfig) (*accountRegistry, error) {
+	if len(config.Discoveries) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Discoveries must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	var emptyAWSConfig clientaws.Config
+	if config.AWSConfig == emptyAWSConfig {
+		return nil, microerror.Maskf(invalidConfigError, "%T.AWSConfig must not be empty", config)
+	}
+	if config.RefreshInterval == 0 {
+		config.RefreshInterval = defaultAccountRefreshInterval
+	}
+
+	r := &accountRegistry{
+		discoveries: config.Discoveries,
+		logger:      config.Logger,
+
+		awsConfig:       config.AWSConfig,
+		refreshInterval: config.RefreshInterval,
+	}
+
+	return r, nil
+}
+
+// Accounts returns the resolved accounts. Concurrent callers share a single
+// resolution and the result is reused until the refresh interval passed.
+func (r *accountRegistry) Accounts(ctx context.Context) ([]account, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.accounts != nil && time.Since(r.resolvedAt) < r.refreshInterval {
+		return r.accounts, nil
+	}
+
+	// Failed resolutions are observed as well, as they are usually the slow
+	// ones.
+	timer := prometheus.NewTimer(accountResolutionDuration)
+	defer timer.ObserveDuration()
+
+	accounts, err := r.resolve(ctx)
+	if err != nil {
+		accountResolutionFailures.Inc()
+		return nil, microerror.Mask(err)
+	}
+
+	accountResolvedCount.Set(float64(len(accounts)))
+	deleteStaleAccounts(r.accounts, accounts)
+
+	r.accounts = accounts
+	r.resolvedAt = time.Now()
+
+	return accounts, nil
+}
+
+// GlobalAccounts returns a single resolved account per account ID. It is used
+// by collectors of global AWS services, whose results do not depend on the
+// region the clients are configured for.
+func (r *accountRegistry) GlobalAccounts(ctx context.Context) ([]account, error) {
+	accounts, err := r.Accounts(ctx)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return globalAccounts(accounts, r.awsConfig.Region), nil
+}
+
+// Collect emits the metrics about account resolution.
+func (r *accountRegistry) Collect(ch chan<- prometheus.Metric) error {
+	accountResolutionDuration.Collect(ch)
+	accountResolutionFailures.Collect(ch)
+	accountDiscoveryFailures.Collect(ch)
+	accountResolvedCount.Collect(ch)
+	accountErrors.Collect(ch)
+	accountUp.Collect(ch)
+	return nil
+}
+
+// Describe emits the description for the metrics collected here.
+func (r *accountRegistry) Describe(ch chan<- *prometheus.Desc) error {
+	accountResolutionDuration.Describe(ch)
+	accountResolutionFailures.Describe(ch)
+	accountDiscoveryFailures.Describe(ch)
+	accountResolvedCount.Describe(ch)
+	accountErrors.Describe(ch)
+	accountUp.Describe(ch)
+	return nil
+}
+
+// resolve discovers all clusters and creates AWS clients for every unique pair
+// of account and region found, using the account ID and region as key to
+// guarantee uniqueness.
+func (r *accountRegistry) resolve(ctx context.Context) ([]account, error) {
+	roles := r.roles(ctx)
+
+	accountsMap := make(map[accountKey]account)
+
+	addAccountFunc := func(awsConfig clientaws.Config, regions []string) error {
+		var accountID string
+		for _, region := range regions {
+			c := awsConfig
+			c.Region = region
+
+			// The account ID does not depend on the region, so it is looked
+			// up only once per account. It is needed before creating the
+			// clients used for collection, as several roles of the same
+			// account must share its rate limits.
+			if accountID == "" {
+				lookupClients, err := clientaws.NewClients(c)
+				if err != nil {
+					return microerror.Mask(err)
+				}
+
+				accountID, err = r.accountID(ctx, lookupClients)
+				if err != nil {
+					return microerror.Mask(err)
+				}
+			}
+			c.AccountID = accountID
+
+			awsClients, err := clientaws.NewClients(c)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			k := accountKey{ID: accountID, Region: region}
+			_, ok := accountsMap[k]
+			if !ok {
+				accountsMap[k] = account{ID: accountID, Region: region, Clients: awsClients}
+			}
+		}
+
+		return nil
+	}
+
+	// Control plane account.
+	err := addAccountFunc(r.awsConfig, roles[role{}])
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	delete(roles, role{})
+
+	// Tenant cluster accounts. A single account failing to resolve, e.g. due
+	// to a rotated role, must not prevent collecting metrics for all others.
+	for ro, regions := range roles {
+		awsConfig := r.awsConfig
+		awsConfig.ExternalID = ro.ExternalID
+		awsConfig.RoleARN = ro.ARN
+
+		err = addAccountFunc(awsConfig, regions)
+		if err != nil {
+			for _, region := range regions {
+				accountErrors.WithLabelValues(collectorAccountResolution, accountIDFromARN(ro.ARN), region, accountErrorReason(err)).Inc()
+			}
+			r.logger.Log("level", "warning", "message", fmt.Sprintf("failed resolving account for role %#q", ro.ARN), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+	}
+
+	accounts := make([]account, 0, len(accountsMap))
+	for k, a := range accountsMap {
+		accounts = append(accounts, a)
+		r.logger.Log("level", "debug", "message", fmt.Sprintf("collecting metrics in account %s and region %s", k.ID, k.Region))
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].ID != accounts[j].ID {
+			return accounts[i].ID < accounts[j].ID
+		}
+		return accounts[i].Region < accounts[j].Region
+	})
+
+	return accounts, nil
+}
+
+// roles returns the targets of all discoveries grouped by role. A single
+// discovery failing, e.g. due to missing permissions, must not prevent
+// collecting metrics for the clusters found by all others.
+func (r *accountRegistry) roles(ctx context.Context) map[role][]string {
+	var targets []target
+	for _, d := range r.discoveries {
+		t, err := d.Targets(ctx)
+		if err != nil {
+			accountDiscoveryFailures.WithLabelValues(d.Name()).Inc()
+			r.logger.Log("level", "warning", "message", fmt.Sprintf("failed discovering clusters with %s discovery", d.Name()), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		targets = append(targets, t...)
+	}
+
+	return groupTargets(targets, r.awsConfig.Region)
+}
+
+// accountID returns the AWS account ID the given clients operate in.
+func (r *accountRegistry) accountID(ctx context.Context, awsClients clientaws.Clients) (string, error) {
+	var err error
+
+	var accountIDService *accountid.AccountID
+	{
+		c := accountid.Config{
+			Logger: r.logger,
+			STS:    awsClients.STS,
+		}
+
+		accountIDService, err = accountid.New(c)
+		if err != nil {
+			return "", microerror.Mask(err)
+		}
+	}
+
+	accountID, err := accountIDService.Lookup(ctx)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return accountID, nil
+}
+
+// accountErrorReason maps the given error to one of the bounded reasons used
+// as label value of the account error metrics.
+func accountErrorReason(err error) string {
+	if IsEndpointNotAvailable(err) {
+		return reasonEndpointUnavailable
+	}
+
+	c := microerror.Cause(err)
+	if request.IsErrorThrottle(c) {
+		return reasonThrottling
+	}
+
+	aerr, ok := c.(awserr.Error)
+	if !ok {
+		return reasonOther
+	}
+	switch aerr.Code() {
+	case "AccessDenied", "AccessDeniedException", "AuthFailure", "ExpiredToken", "ExpiredTokenException", "InvalidClientTokenId", "UnauthorizedOperation", "UnrecognizedClientException":
+		return reasonAccessDenied
+	}
+	rerr, ok := c.(awserr.RequestFailure)
+	if ok && rerr.StatusCode() == http.StatusForbidden {
+		return reasonAccessDenied
+	}
+
+	return reasonOther
+}
+
+// accountIDFromARN returns the account ID part of the given IAM ARN, or the
+// whole ARN in case it cannot be parsed.
+func accountIDFromARN(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) <= accountIDIndexARN {
+		return arn
+	}
+
+	return parts[accountIDIndexARN]
+}
+
+// groupTargets returns the sorted unique regions of the given targets keyed by
+// their role. Targets without region are located in the given control plane
+// region. The host account is always included for the control plane region.
+func groupTargets(targets []target, controlPlaneRegion string) map[role][]string {
+	regionsMap := map[role]map[string]bool{
+		{}: {controlPlaneRegion: true},
+	}
+	for _, t := range targets {
+		ro := role{ARN: t.RoleARN, ExternalID: t.ExternalID}
+
+		region := t.Region
+		if region == "" {
+			region = controlPlaneRegion
+		}
+
+		if regionsMap[ro] == nil {
+			regionsMap[ro] = map[string]bool{}
+		}
+		regionsMap[ro][region] = true
+	}
+
+	roles := make(map[role][]string, len(regionsMap))
+	for ro, m := range regionsMap {
+		var regions []string
+		for region := range m {
+			regions = append(regions, region)
+		}
+		sort.Strings(regions)
+
+		roles[ro] = regions
+	}
+
+	return roles
+}
+
+// globalAccounts returns one of the given accounts per account ID, preferring
+// the one in the given region. The order of the given accounts is preserved.
+func globalAccounts(accounts []account, preferredRegion string) []account {
+	selected := map[string]int{}
+
+	var global []account
+	for _, a := range accounts {
+		i, ok := selected[a.ID]
+		if !ok {
+			selected[a.ID] = len(global)
+			global = append(global, a)
+			continue
+		}
+
+		if a.Region == preferredRegion {
+			global[i] = a
+		}
+	}
+
+	return global
+}
+
+// deleteStaleAccounts removes the per account metrics of accounts and regions
+// which are not resolved anymore, e.g. because all clusters of that account in
+// that region got deleted.
+func deleteStaleAccounts(previous []account, current []account) {
+	keys := make(map[accountKey]bool, len(current))
+	for _, a := range current {
+		keys[accountKey{ID: a.ID, Region: a.Region}] = true
+	}
+
+	for _, a := range previous {
+		if keys[accountKey{ID: a.ID, Region: a.Region}] {
+			continue
+		}
+
+		labels := prometheus.Labels{
+			labelAccountID: a.ID,
+			labelRegion:    a.Region,
+		}
+
+		accountUp.DeletePartialMatch(labels)
+		collectorAccountDuration.DeletePartialMatch(labels)
+	}
+}
@@ -0,0 +1,276 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger/microloggertest"
+
+	clientaws "github.com/giantswarm/aws-collector/client/aws"
+)
+
+func TestAccountErrorReason(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+
+		expectedReason string
+	}{
+		{
+			name: "case 0: access denied",
+			err:  awserr.New("AccessDenied", "not authorized to perform sts:AssumeRole", nil),
+
+			expectedReason: reasonAccessDenied,
+		},
+		{
+			name: "case 1: masked unauthorized operation",
+			err:  microerror.Mask(awserr.New("UnauthorizedOperation", "not authorized", nil)),
+
+			expectedReason: reasonAccessDenied,
+		},
+		{
+			name: "case 2: throttling",
+			err:  awserr.New("Throttling", "rate exceeded", nil),
+
+			expectedReason: reasonThrottling,
+		},
+		{
+			name: "case 3: request limit exceeded",
+			err:  microerror.Mask(awserr.New("RequestLimitExceeded", "request limit exceeded", nil)),
+
+			expectedReason: reasonThrottling,
+		},
+		{
+			name: "case 4: forbidden request failure",
+			err:  awserr.NewRequestFailure(awserr.New("Forbidden", "forbidden", nil), 403, "id"),
+
+			expectedReason: reasonAccessDenied,
+		},
+		{
+			name: "case 5: endpoint not available",
+			err:  errors.New("dial tcp: lookup servicequotas.ap-east-1.amazonaws.com: no such host"),
+
+			expectedReason: reasonEndpointUnavailable,
+		},
+		{
+			name: "case 6: unknown error",
+			err:  errors.New("something went wrong"),
+
+			expectedReason: reasonOther,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			reason := accountErrorReason(tc.err)
+
+			if reason != tc.expectedReason {
+				t.Fatalf("expected %#q, got %#q", tc.expectedReason, reason)
+			}
+		})
+	}
+}
+
+func TestAccountIDFromARN(t *testing.T) {
+	testCases := []struct {
+		name string
+		arn  string
+
+		expectedAccountID string
+	}{
+		{
+			name: "case 0",
+			arn:  "arn:aws:iam::123456789012:role/GiantSwarmAWSOperator",
+
+			expectedAccountID: "123456789012",
+		},
+		{
+			name: "case 1",
+			arn:  "invalid",
+
+			expectedAccountID: "invalid",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			accountID := accountIDFromARN(tc.arn)
+
+			if accountID != tc.expectedAccountID {
+				t.Fatalf("expected %#q, got %#q", tc.expectedAccountID, accountID)
+			}
+		})
+	}
+}
+
+func TestGlobalAccounts(t *testing.T) {
+	testCases := []struct {
+		name            string
+		accounts        []account
+		preferredRegion string
+
+		expectedAccounts []account
+	}{
+		{
+			name: "case 0: single region accounts are kept",
+			accounts: []account{
+				{ID: "111111111111", Region: "eu-west-1"},
+				{ID: "222222222222", Region: "us-east-1"},
+			},
+			preferredRegion: "eu-central-1",
+
+			expectedAccounts: []account{
+				{ID: "111111111111", Region: "eu-west-1"},
+				{ID: "222222222222", Region: "us-east-1"},
+			},
+		},
+		{
+			name: "case 1: preferred region is selected",
+			accounts: []account{
+				{ID: "111111111111", Region: "eu-central-1"},
+				{ID: "111111111111", Region: "eu-west-1"},
+				{ID: "222222222222", Region: "us-east-1"},
+			},
+			preferredRegion: "eu-west-1",
+
+			expectedAccounts: []account{
+				{ID: "111111111111", Region: "eu-west-1"},
+				{ID: "222222222222", Region: "us-east-1"},
+			},
+		},
+		{
+			name: "case 2: first region is selected without preferred one",
+			accounts: []account{
+				{ID: "111111111111", Region: "eu-central-1"},
+				{ID: "111111111111", Region: "us-west-2"},
+			},
+			preferredRegion: "eu-west-1",
+
+			expectedAccounts: []account{
+				{ID: "111111111111", Region: "eu-central-1"},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			accounts := globalAccounts(tc.accounts, tc.preferredRegion)
+
+			if !reflect.DeepEqual(accounts, tc.expectedAccounts) {
+				t.Fatalf("expected %#v, got %#v", tc.expectedAccounts, accounts)
+			}
+		})
+	}
+}
+
+func TestGroupTargets(t *testing.T) {
+	testCases := []struct {
+		name    string
+		targets []target
+
+		expectedRoles map[role][]string
+	}{
+		{
+			name: "case 0: host account only",
+
+			expectedRoles: map[role][]string{
+				{}: {"eu-central-1"},
+			},
+		},
+		{
+			name: "case 1: roles and regions are deduplicated",
+			targets: []target{
+				{RoleARN: "arn:aws:iam::111111111111:role/a"},
+				{RoleARN: "arn:aws:iam::111111111111:role/a", Region: "eu-central-1"},
+				{RoleARN: "arn:aws:iam::111111111111:role/a", Region: "us-east-1"},
+				{RoleARN: "arn:aws:iam::222222222222:role/b", ExternalID: "x", Region: "eu-west-1"},
+				{Region: "us-west-2"},
+			},
+
+			expectedRoles: map[role][]string{
+				{}: {"eu-central-1", "us-west-2"},
+				{ARN: "arn:aws:iam::111111111111:role/a"}:                  {"eu-central-1", "us-east-1"},
+				{ARN: "arn:aws:iam::222222222222:role/b", ExternalID: "x"}: {"eu-west-1"},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			roles := groupTargets(tc.targets, "eu-central-1")
+
+			if !reflect.DeepEqual(roles, tc.expectedRoles) {
+				t.Fatalf("expected %#v, got %#v", tc.expectedRoles, roles)
+			}
+		})
+	}
+}
+
+func TestAccountRegistryRoles(t *testing.T) {
+	testCases := []struct {
+		name        string
+		discoveries []clusterDiscovery
+
+		expectedRoles map[role][]string
+	}{
+		{
+			name: "case 0: targets of all discoveries are grouped",
+			discoveries: []clusterDiscovery{
+				&fakeDiscovery{targets: []target{{RoleARN: "arn:aws:iam::111111111111:role/a", Region: "eu-west-1"}}},
+				&fakeDiscovery{targets: []target{{Region: "us-east-1"}}},
+			},
+
+			expectedRoles: map[role][]string{
+				{}: {"eu-central-1", "us-east-1"},
+				{ARN: "arn:aws:iam::111111111111:role/a"}: {"eu-west-1"},
+			},
+		},
+		{
+			name: "case 1: failing discovery does not drop the targets of others",
+			discoveries: []clusterDiscovery{
+				&fakeDiscovery{err: errors.New("forbidden")},
+				&fakeDiscovery{targets: []target{{RoleARN: "arn:aws:iam::111111111111:role/a", Region: "eu-west-1"}}},
+			},
+
+			expectedRoles: map[role][]string{
+				{}: {"eu-central-1"},
+				{ARN: "arn:aws:iam::111111111111:role/a"}: {"eu-west-1"},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			r := &accountRegistry{
+				discoveries: tc.discoveries,
+				logger:      microloggertest.New(),
+
+				awsConfig: clientaws.Config{Region: "eu-central-1"},
+			}
+
+			roles := r.roles(context.Background())
+
+			if !reflect.DeepEqual(roles, tc.expectedRoles) {
+				t.Fatalf("expected %#v, got %#v", tc.expectedRoles, roles)
+			}
+		})
+	}
+}
+
+type fakeDiscovery struct {
+	targets []target
+	err     error
+}
+
+func (f *fakeDiscovery) Name() string {
+	return "fake"
+}
+
+func (f *fakeDiscovery) Targets(ctx context.Context) ([]target, error) {
+	return f.targets, f.err
+}
@@ -2,20 +2,60 @@ package collector
 
 import (
 	"context"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
-	"golang.org/x/sync/errgroup"
 
-	clientaws "github.com/giantswarm/aws-collector/client/aws"
 	"github.com/giantswarm/aws-collector/service/controller/key"
+	"github.com/giantswarm/aws-collector/service/internal/cache"
 )
 
 const (
 	// labelASG is the metric's label key that will hold the ASG name.
 	labelASG = "asg"
+
+	// labelLifecycleState is the metric's label key that will hold the
+	// lifecycle state of ASG instances, e.g. InService.
+	labelLifecycleState = "lifecycle_state"
+
+	// labelProcess is the metric's label key that will hold the name of a
+	// scaling process, e.g. Launch.
+	labelProcess = "process"
+
+	// labelStatus is the metric's label key that will hold the status of an
+	// instance refresh.
+	labelStatus = "status"
+)
+
+const (
+	lifecycleStatePending     = "Pending"
+	lifecycleStateInService   = "InService"
+	lifecycleStateStandby     = "Standby"
+	lifecycleStateTerminating = "Terminating"
+	lifecycleStateWarmed      = "Warmed"
+
+	// failedActivitiesWindow is the period in which failed scaling activities
+	// are counted.
+	failedActivitiesWindow = time.Hour
+	// inactiveInstanceRefreshExpiration is the period for which ASGs without
+	// active instance refresh are not checked again. Instance refreshes are
+	// started rarely, while the progress of active ones is checked on every
+	// collection.
+	inactiveInstanceRefreshExpiration = 5 * time.Minute
+)
+
+const (
+	failedLaunchReasonInsufficientCapacity = "insufficient_capacity"
+	failedLaunchReasonLaunchTemplateError  = "launch_template_error"
+	failedLaunchReasonOther                = "other"
+	failedLaunchReasonSpotUnavailable      = "spot_unavailable"
+	failedLaunchReasonVCPULimit            = "vcpu_limit"
 )
 
 const (
@@ -31,6 +71,7 @@ var (
 		[]string{
 			labelASG,
 			labelAccount,
+			labelRegion,
 			labelCluster,
 			labelInstallation,
 			labelOrganization,
@@ -44,12 +85,175 @@ var (
 		[]string{
 			labelASG,
 			labelAccount,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+		},
+		nil,
+	)
+
+	asgInstanceCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemASG, "instance_count"),
+		"Gauge about the number of EC2 instances in the ASG by lifecycle state. Warmed includes the instances of the warm pool.",
+		[]string{
+			labelASG,
+			labelAccount,
+			labelRegion,
 			labelCluster,
 			labelInstallation,
 			labelOrganization,
+			labelLifecycleState,
 		},
 		nil,
 	)
+
+	asgMinSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemASG, "min_size"),
+		"Gauge about the minimum number of EC2 instances of the ASG.",
+		[]string{
+			labelASG,
+			labelAccount,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+		},
+		nil,
+	)
+
+	asgMaxSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemASG, "max_size"),
+		"Gauge about the maximum number of EC2 instances of the ASG.",
+		[]string{
+			labelASG,
+			labelAccount,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+		},
+		nil,
+	)
+
+	asgInstanceRefreshPercentageDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemASG, "instance_refresh_percentage_complete"),
+		"Gauge about the completion percentage of the active instance refresh of the ASG, labelled with its status.",
+		[]string{
+			labelASG,
+			labelAccount,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+			labelStatus,
+		},
+		nil,
+	)
+
+	asgInstanceRefreshStartTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemASG, "instance_refresh_start_time_seconds"),
+		"Gauge about the start of the active instance refresh of the ASG as Unix timestamp in seconds.",
+		[]string{
+			labelASG,
+			labelAccount,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+			labelStatus,
+		},
+		nil,
+	)
+
+	asgSuspendedProcessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemASG, "suspended_process"),
+		"Gauge about the scaling processes suspended on the ASG. Always 1.",
+		[]string{
+			labelASG,
+			labelAccount,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+			labelProcess,
+		},
+		nil,
+	)
+
+	asgFailedActivitiesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemASG, "failed_activities_count"),
+		"Gauge about the number of failed scaling activities of the ASG started within the last hour.",
+		[]string{
+			labelASG,
+			labelAccount,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+		},
+		nil,
+	)
+
+	asgFailedLaunchesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemASG, "failed_launches_count"),
+		"Gauge about the number of failed scaling activities of the ASG started within the last hour by classified reason.",
+		[]string{
+			labelASG,
+			labelAccount,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+			labelReason,
+		},
+		nil,
+	)
+)
+
+var (
+	// failedLaunchReasons classify the status messages of failed scaling
+	// activities. They are matched in order against the lower-cased status
+	// message, so that e.g. a vCPU limit hit by spot instances is classified
+	// as vcpu_limit.
+	failedLaunchReasons = []struct {
+		Reason   string
+		Patterns []string
+	}{
+		{
+			Reason:   failedLaunchReasonVCPULimit,
+			Patterns: []string{"vcpulimitexceeded", "vcpu limit", "instancelimitexceeded"},
+		},
+		{
+			Reason:   failedLaunchReasonSpotUnavailable,
+			Patterns: []string{"insufficientspotcapacity", "spotmaxpricetoolow", "capacity-not-available", "no spot capacity", "spot instances. unfulfillablecapacity"},
+		},
+		{
+			Reason:   failedLaunchReasonInsufficientCapacity,
+			Patterns: []string{"insufficientinstancecapacity", "insufficient capacity", "do not have sufficient"},
+		},
+		{
+			Reason:   failedLaunchReasonLaunchTemplateError,
+			Patterns: []string{"launch template", "launchtemplate", "invalidamiid", "invalidparameter", "invalid iam instance profile"},
+		},
+	}
+
+	// activeInstanceRefreshStatuses are the statuses of instance refreshes
+	// which are not finished yet.
+	activeInstanceRefreshStatuses = map[string]bool{
+		autoscaling.InstanceRefreshStatusPending:            true,
+		autoscaling.InstanceRefreshStatusInProgress:         true,
+		autoscaling.InstanceRefreshStatusCancelling:         true,
+		autoscaling.InstanceRefreshStatusRollbackInProgress: true,
+	}
+
+	// finishedScalingActivityStatuses are the status codes of scaling
+	// activities which are finished.
+	finishedScalingActivityStatuses = map[string]bool{
+		autoscaling.ScalingActivityStatusCodeCancelled:  true,
+		autoscaling.ScalingActivityStatusCodeFailed:     true,
+		autoscaling.ScalingActivityStatusCodeSuccessful: true,
+	}
 )
 
 // ASGConfig is this collector's configuration struct.
@@ -62,8 +266,9 @@ type ASGConfig struct {
 
 // ASG is the main struct for this collector.
 type ASG struct {
-	helper *helper
-	logger micrologger.Logger
+	helper       *helper
+	logger       micrologger.Logger
+	refreshCache *cache.Cache[*autoscaling.InstanceRefresh]
 
 	installationName string
 }
@@ -82,8 +287,9 @@ func NewASG(config ASGConfig) (*ASG, error) {
 	}
 
 	a := &ASG{
-		helper: config.Helper,
-		logger: config.Logger,
+		helper:       config.Helper,
+		logger:       config.Logger,
+		refreshCache: cache.NewCache[*autoscaling.InstanceRefresh](inactiveInstanceRefreshExpiration),
 
 		installationName: config.InstallationName,
 	}
@@ -92,33 +298,15 @@ func NewASG(config ASGConfig) (*ASG, error) {
 }
 
 // Collect is the main metrics collection function.
-func (a *ASG) Collect(ch chan<- prometheus.Metric) error {
-	reconciledClusters, err := a.helper.ListReconciledClusters()
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	awsClientsList, err := a.helper.GetAWSClients(context.Background(), reconciledClusters)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	var g errgroup.Group
-
-	for _, item := range awsClientsList {
-		awsClients := item
-
-		g.Go(func() error {
-			err := a.collectForAccount(ch, awsClients)
-			if err != nil {
-				return microerror.Mask(err)
-			}
-
-			return nil
-		})
-	}
+func (a *ASG) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	err := a.helper.ForEachAccount(ctx, collectorASG, func(acc account) error {
+		err := a.collectForAccount(ctx, ch, acc)
+		if err != nil {
+			return microerror.Mask(err)
+		}
 
-	err = g.Wait()
+		return nil
+	})
 	if err != nil {
 		return microerror.Mask(err)
 	}
@@ -130,76 +318,287 @@ func (a *ASG) Collect(ch chan<- prometheus.Metric) error {
 func (a *ASG) Describe(ch chan<- *prometheus.Desc) error {
 	ch <- asgDesiredDesc
 	ch <- asgInserviceDesc
+	ch <- asgInstanceCountDesc
+	ch <- asgMinSizeDesc
+	ch <- asgMaxSizeDesc
+	ch <- asgInstanceRefreshPercentageDesc
+	ch <- asgInstanceRefreshStartTimeDesc
+	ch <- asgSuspendedProcessDesc
+	ch <- asgFailedActivitiesDesc
+	ch <- asgFailedLaunchesDesc
 	return nil
 }
 
 // collectForAccount collects and emits metrics for one AWS account.
-func (a *ASG) collectForAccount(ch chan<- prometheus.Metric, awsClients clientaws.Clients) error {
-	account, err := a.helper.AWSAccountID(awsClients)
+func (a *ASG) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+	var autoScalingGroups []*autoscaling.Group
+	err := acc.Clients.AutoScaling.DescribeAutoScalingGroupsPagesWithContext(ctx, &autoscaling.DescribeAutoScalingGroupsInput{}, func(o *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+		autoScalingGroups = append(autoScalingGroups, o.AutoScalingGroups...)
+		return true
+	})
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	var nextToken *string
-	for {
-		var autoScalingGroups []*autoscaling.Group
-		{
-			i := &autoscaling.DescribeAutoScalingGroupsInput{
-				NextToken: nextToken,
-			}
-			o, err := awsClients.AutoScaling.DescribeAutoScalingGroups(i)
-			if err != nil {
-				return microerror.Mask(err)
+	activities, err := recentScalingActivities(ctx, acc.Clients.AutoScaling, time.Now().Add(-failedActivitiesWindow))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, asg := range autoScalingGroups {
+		var cluster, installation, organization string
+
+		for _, tag := range asg.Tags {
+			switch *tag.Key {
+			case tagCluster:
+				cluster = *tag.Value
+			case key.TagInstallation:
+				installation = *tag.Value
+			case tagOrganization:
+				organization = *tag.Value
 			}
-			autoScalingGroups = o.AutoScalingGroups
-			nextToken = o.NextToken
 		}
 
-		for _, asg := range autoScalingGroups {
-			var cluster, installation, organization string
-
-			for _, tag := range asg.Tags {
-				switch *tag.Key {
-				case tagCluster:
-					cluster = *tag.Value
-				case key.TagInstallation:
-					installation = *tag.Value
-				case tagOrganization:
-					organization = *tag.Value
-				}
-			}
+		if installation != a.installationName {
+			continue
+		}
 
-			if installation != a.installationName {
-				continue
-			}
+		labels := []string{
+			*asg.AutoScalingGroupName,
+			acc.ID,
+			acc.Region,
+			cluster,
+			installation,
+			organization,
+		}
+
+		counts := lifecycleStateCounts(asg)
 
+		ch <- prometheus.MustNewConstMetric(
+			asgDesiredDesc,
+			prometheus.GaugeValue,
+			float64(*asg.DesiredCapacity),
+			labels...,
+		)
+
+		ch <- prometheus.MustNewConstMetric(
+			asgInserviceDesc,
+			prometheus.GaugeValue,
+			float64(counts[lifecycleStateInService]),
+			labels...,
+		)
+
+		for state, count := range counts {
 			ch <- prometheus.MustNewConstMetric(
-				asgDesiredDesc,
+				asgInstanceCountDesc,
 				prometheus.GaugeValue,
-				float64(*asg.DesiredCapacity),
-				*asg.AutoScalingGroupName,
-				account,
-				cluster,
-				installation,
-				organization,
+				float64(count),
+				append(labels, state)...,
 			)
+		}
 
+		ch <- prometheus.MustNewConstMetric(
+			asgMinSizeDesc,
+			prometheus.GaugeValue,
+			float64(aws.Int64Value(asg.MinSize)),
+			labels...,
+		)
+
+		ch <- prometheus.MustNewConstMetric(
+			asgMaxSizeDesc,
+			prometheus.GaugeValue,
+			float64(aws.Int64Value(asg.MaxSize)),
+			labels...,
+		)
+
+		for _, p := range asg.SuspendedProcesses {
 			ch <- prometheus.MustNewConstMetric(
-				asgInserviceDesc,
+				asgSuspendedProcessDesc,
 				prometheus.GaugeValue,
-				float64(len(asg.Instances)),
-				*asg.AutoScalingGroupName,
-				account,
-				cluster,
-				installation,
-				organization,
+				GaugeValue,
+				append(labels, aws.StringValue(p.ProcessName))...,
 			)
 		}
 
-		if nextToken == nil {
-			break
+		refresh, err := a.activeInstanceRefresh(ctx, acc, asg.AutoScalingGroupName)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if refresh != nil {
+			status := aws.StringValue(refresh.Status)
+
+			ch <- prometheus.MustNewConstMetric(
+				asgInstanceRefreshPercentageDesc,
+				prometheus.GaugeValue,
+				float64(aws.Int64Value(refresh.PercentageComplete)),
+				append(labels, status)...,
+			)
+
+			if refresh.StartTime != nil {
+				ch <- prometheus.MustNewConstMetric(
+					asgInstanceRefreshStartTimeDesc,
+					prometheus.GaugeValue,
+					float64(refresh.StartTime.Unix()),
+					append(labels, status)...,
+				)
+			}
+		}
+
+		failed := failedActivities(activities[aws.StringValue(asg.AutoScalingGroupName)])
+
+		ch <- prometheus.MustNewConstMetric(
+			asgFailedActivitiesDesc,
+			prometheus.GaugeValue,
+			float64(len(failed)),
+			labels...,
+		)
+
+		for reason, count := range failedLaunchReasonCounts(failed) {
+			ch <- prometheus.MustNewConstMetric(
+				asgFailedLaunchesDesc,
+				prometheus.GaugeValue,
+				float64(count),
+				append(labels, reason)...,
+			)
 		}
 	}
 
 	return nil
 }
+
+// activeInstanceRefresh returns the instance refresh of the given ASG which is
+// not finished yet, if any. Only one instance refresh can be active at a
+// time, and the most recent one is returned first. ASGs without active
+// instance refresh are cached for inactiveInstanceRefreshExpiration.
+func (a *ASG) activeInstanceRefresh(ctx context.Context, acc account, asgName *string) (*autoscaling.InstanceRefresh, error) {
+	cacheKey := acc.ID + "/" + acc.Region + "/" + aws.StringValue(asgName)
+
+	if refresh, ok := a.refreshCache.Get(cacheKey); ok {
+		return refresh, nil
+	}
+
+	i := &autoscaling.DescribeInstanceRefreshesInput{
+		AutoScalingGroupName: asgName,
+		MaxRecords:           aws.Int64(1),
+	}
+
+	o, err := acc.Clients.AutoScaling.DescribeInstanceRefreshesWithContext(ctx, i)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for _, r := range o.InstanceRefreshes {
+		if activeInstanceRefreshStatuses[aws.StringValue(r.Status)] {
+			return r, nil
+		}
+	}
+
+	a.refreshCache.Set(cacheKey, nil)
+
+	return nil, nil
+}
+
+// recentScalingActivities returns the scaling activities of all ASGs of the
+// account started after since, keyed by ASG name. Activities still in
+// progress are returned first, the others newest first, so pagination stops
+// at the first older finished activity.
+func recentScalingActivities(ctx context.Context, client autoscalingiface.AutoScalingAPI, since time.Time) (map[string][]*autoscaling.Activity, error) {
+	activities := map[string][]*autoscaling.Activity{}
+
+	i := &autoscaling.DescribeScalingActivitiesInput{}
+
+	err := client.DescribeScalingActivitiesPagesWithContext(ctx, i, func(o *autoscaling.DescribeScalingActivitiesOutput, lastPage bool) bool {
+		for _, a := range o.Activities {
+			if a.StartTime == nil || a.StartTime.Before(since) {
+				if !finishedScalingActivityStatuses[aws.StringValue(a.StatusCode)] {
+					continue
+				}
+				return false
+			}
+
+			name := aws.StringValue(a.AutoScalingGroupName)
+			activities[name] = append(activities[name], a)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return activities, nil
+}
+
+// failedActivities returns the given scaling activities which failed.
+func failedActivities(activities []*autoscaling.Activity) []*autoscaling.Activity {
+	var failed []*autoscaling.Activity
+	for _, a := range activities {
+		if aws.StringValue(a.StatusCode) == autoscaling.ScalingActivityStatusCodeFailed {
+			failed = append(failed, a)
+		}
+	}
+
+	return failed
+}
+
+// failedLaunchReason classifies the given status message of a failed scaling
+// activity into one of the reasons of failedLaunchReasons, or other.
+func failedLaunchReason(statusMessage string) string {
+	m := strings.ToLower(statusMessage)
+
+	for _, r := range failedLaunchReasons {
+		for _, p := range r.Patterns {
+			if strings.Contains(m, p) {
+				return r.Reason
+			}
+		}
+	}
+
+	return failedLaunchReasonOther
+}
+
+// failedLaunchReasonCounts returns the number of the given failed scaling
+// activities by classified reason. All reasons are part of the result.
+func failedLaunchReasonCounts(activities []*autoscaling.Activity) map[string]int {
+	counts := map[string]int{
+		failedLaunchReasonOther: 0,
+	}
+	for _, r := range failedLaunchReasons {
+		counts[r.Reason] = 0
+	}
+
+	for _, a := range activities {
+		counts[failedLaunchReason(aws.StringValue(a.StatusMessage))]++
+	}
+
+	return counts
+}
+
+// lifecycleStateCounts returns the number of instances of the given ASG by
+// lifecycle state. Sub-states like Pending:Wait are counted as their main
+// state and the instances of the warm pool are counted as Warmed. All states
+// are part of the result.
+func lifecycleStateCounts(asg *autoscaling.Group) map[string]int {
+	counts := map[string]int{
+		lifecycleStatePending:     0,
+		lifecycleStateInService:   0,
+		lifecycleStateStandby:     0,
+		lifecycleStateTerminating: 0,
+		lifecycleStateWarmed:      int(aws.Int64Value(asg.WarmPoolSize)),
+	}
+
+	for _, i := range asg.Instances {
+		state, _, _ := strings.Cut(aws.StringValue(i.LifecycleState), ":")
+		if state == autoscaling.LifecycleStateEnteringStandby {
+			state = lifecycleStateStandby
+		}
+
+		_, ok := counts[state]
+		if !ok || state == lifecycleStateWarmed {
+			continue
+		}
+
+		counts[state]++
+	}
+
+	return counts
+}
@@ -0,0 +1,196 @@
+package collector
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+func TestLifecycleStateCounts(t *testing.T) {
+	testCases := []struct {
+		name string
+		asg  *autoscaling.Group
+
+		expectedCounts map[string]int
+	}{
+		{
+			name: "case 0: empty ASG still reports all states",
+			asg:  &autoscaling.Group{},
+
+			expectedCounts: map[string]int{
+				"Pending":     0,
+				"InService":   0,
+				"Standby":     0,
+				"Terminating": 0,
+				"Warmed":      0,
+			},
+		},
+		{
+			name: "case 1: sub-states are counted as their main state",
+			asg: &autoscaling.Group{
+				Instances: []*autoscaling.Instance{
+					{LifecycleState: aws.String("InService")},
+					{LifecycleState: aws.String("InService")},
+					{LifecycleState: aws.String("Pending:Wait")},
+					{LifecycleState: aws.String("Pending")},
+					{LifecycleState: aws.String("Terminating:Proceed")},
+					{LifecycleState: aws.String("EnteringStandby")},
+					{LifecycleState: aws.String("Standby")},
+					{LifecycleState: aws.String("Detaching")},
+				},
+				WarmPoolSize: aws.Int64(3),
+			},
+
+			expectedCounts: map[string]int{
+				"Pending":     2,
+				"InService":   2,
+				"Standby":     2,
+				"Terminating": 1,
+				"Warmed":      3,
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			counts := lifecycleStateCounts(tc.asg)
+
+			if !reflect.DeepEqual(counts, tc.expectedCounts) {
+				t.Fatalf("expected %#v, got %#v", tc.expectedCounts, counts)
+			}
+		})
+	}
+}
+
+func TestFailedLaunchReason(t *testing.T) {
+	testCases := []struct {
+		name          string
+		statusMessage string
+
+		expectedReason string
+	}{
+		{
+			name:          "case 0: insufficient capacity",
+			statusMessage: "We currently do not have sufficient m5.xlarge capacity in the Availability Zone you requested (eu-west-1a). Our system will be working on provisioning additional capacity. Launching EC2 instance failed.",
+
+			expectedReason: "insufficient_capacity",
+		},
+		{
+			name:          "case 1: vCPU limit",
+			statusMessage: "You have requested more vCPU capacity than your current vCPU limit of 32 allows for the instance bucket that the specified instance type belongs to. Please visit http://aws.amazon.com/contact-us/ec2-request to request an adjustment to this limit. Launching EC2 instance failed.",
+
+			expectedReason: "vcpu_limit",
+		},
+		{
+			name:          "case 2: vCPU limit of spot instances",
+			statusMessage: "Could not launch Spot Instances. VcpuLimitExceeded - You have requested more vCPU capacity than your current vCPU limit of 16 allows. Launching EC2 instance failed.",
+
+			expectedReason: "vcpu_limit",
+		},
+		{
+			name:          "case 3: spot capacity",
+			statusMessage: "Could not launch Spot Instances. UnfulfillableCapacity - Unable to fulfill capacity due to your request configuration. Launching EC2 instance failed.",
+
+			expectedReason: "spot_unavailable",
+		},
+		{
+			name:          "case 4: invalid AMI in launch template",
+			statusMessage: "The image id '[ami-0123456789abcdef0]' does not exist. InvalidAMIID.NotFound. Launching EC2 instance failed.",
+
+			expectedReason: "launch_template_error",
+		},
+		{
+			name:          "case 5: deleted launch template",
+			statusMessage: "The specified launch template, with template ID lt-0123456789abcdef0, does not exist.",
+
+			expectedReason: "launch_template_error",
+		},
+		{
+			name:          "case 6: spot max price",
+			statusMessage: "Could not launch Spot Instances. SpotMaxPriceTooLow - Your Spot request price of 0.01 is lower than the minimum required Spot request fulfillment price of 0.0364. Launching EC2 instance failed.",
+
+			expectedReason: "spot_unavailable",
+		},
+		{
+			name:          "case 7: insufficient spot capacity",
+			statusMessage: "Could not launch Spot Instances. InsufficientSpotCapacity - There is no Spot capacity available that matches your request. Launching EC2 instance failed.",
+
+			expectedReason: "spot_unavailable",
+		},
+		{
+			name:          "case 8: invalid spot options of spot ASG",
+			statusMessage: "Could not launch Spot Instances. InvalidParameterValue - The spot options of the launch template are not supported. Launching EC2 instance failed.",
+
+			expectedReason: "launch_template_error",
+		},
+		{
+			name:          "case 9: deleted launch template of spot ASG",
+			statusMessage: "Could not launch Spot Instances. InvalidLaunchTemplateId.NotFound - The specified launch template, with template ID lt-0123456789abcdef0, does not exist. Launching EC2 instance failed.",
+
+			expectedReason: "launch_template_error",
+		},
+		{
+			name:          "case 10: unknown",
+			statusMessage: "Instance became unhealthy while waiting for instance to be in InService state.",
+
+			expectedReason: "other",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			reason := failedLaunchReason(tc.statusMessage)
+
+			if reason != tc.expectedReason {
+				t.Fatalf("expected %#q, got %#q", tc.expectedReason, reason)
+			}
+		})
+	}
+}
+
+func TestRecentScalingActivities(t *testing.T) {
+	now := time.Now()
+
+	fake := &fakeAutoScaling{
+		activities: [][]*autoscaling.Activity{
+			{
+				{ActivityId: aws.String("1"), AutoScalingGroupName: aws.String("asg-1"), StartTime: aws.Time(now.Add(-2 * time.Hour)), StatusCode: aws.String(autoscaling.ScalingActivityStatusCodeInProgress)},
+				{ActivityId: aws.String("2"), AutoScalingGroupName: aws.String("asg-1"), StartTime: aws.Time(now.Add(-time.Minute)), StatusCode: aws.String(autoscaling.ScalingActivityStatusCodeFailed)},
+				{ActivityId: aws.String("3"), AutoScalingGroupName: aws.String("asg-2"), StartTime: aws.Time(now.Add(-2 * time.Minute)), StatusCode: aws.String(autoscaling.ScalingActivityStatusCodeSuccessful)},
+			},
+			{
+				{ActivityId: aws.String("4"), AutoScalingGroupName: aws.String("asg-1"), StartTime: aws.Time(now.Add(-3 * time.Minute)), StatusCode: aws.String(autoscaling.ScalingActivityStatusCodeFailed)},
+				{ActivityId: aws.String("5"), AutoScalingGroupName: aws.String("asg-2"), StartTime: aws.Time(now.Add(-2 * time.Hour)), StatusCode: aws.String(autoscaling.ScalingActivityStatusCodeFailed)},
+			},
+			// Pagination must stop before this page.
+			{
+				{ActivityId: aws.String("6"), AutoScalingGroupName: aws.String("asg-3"), StartTime: aws.Time(now.Add(-time.Minute)), StatusCode: aws.String(autoscaling.ScalingActivityStatusCodeFailed)},
+			},
+		},
+	}
+
+	activities, err := recentScalingActivities(context.Background(), autoscaling.New(newFakeSession(fake)), now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("error == %#v, want nil", err)
+	}
+
+	ids := map[string][]string{}
+	for name, list := range activities {
+		for _, a := range list {
+			ids[name] = append(ids[name], aws.StringValue(a.ActivityId))
+		}
+	}
+
+	expected := map[string][]string{
+		"asg-1": {"2", "4"},
+		"asg-2": {"3"},
+	}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, ids)
+	}
+}
@@ -2,15 +2,19 @@ package collector
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/cloudformation"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
-	"golang.org/x/sync/errgroup"
 
 	clientaws "github.com/giantswarm/aws-collector/client/aws"
 	"github.com/giantswarm/aws-collector/service/controller/key"
+	"github.com/giantswarm/aws-collector/service/internal/cache"
 )
 
 const (
@@ -22,12 +26,20 @@ const (
 	subsystemCloudFormation = "cloudformation"
 )
 
+var (
+	// ownStackNameRegexp matches the names of the stacks created for tenant
+	// clusters, like "cluster-a1b2c-tccp" or "cluster-a1b2c-tcnp-d3e4f". It
+	// narrows down the stacks described for their tags in shared accounts.
+	ownStackNameRegexp = regexp.MustCompile(`^cluster-[a-z0-9]+-(tccp[fin]?|tcnpf?)(-[a-z0-9]+)?$`)
+)
+
 var (
 	cloudFormationStackDesc = prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, subsystemCloudFormation, "info"),
 		"Metrics for Cloud Formation Stack statuses.",
 		[]string{
 			labelAccountID,
+			labelRegion,
 			labelCluster,
 			labelID,
 			labelInstallation,
@@ -45,15 +57,34 @@ type CloudFormationConfig struct {
 	Helper *helper
 	Logger micrologger.Logger
 
-	InstallationName string
+	// DeletedStacksGracePeriod is the period for which deleted stacks are
+	// still reported with state DELETE_COMPLETE. Zero means deleted stacks
+	// are not reported.
+	DeletedStacksGracePeriod time.Duration
+	// DriftDetection enables triggering drift detection of our own stacks.
+	// Drift detection is rate limited by AWS and scans every resource of a
+	// stack, hence it is opt-in.
+	DriftDetection bool
+	// DriftDetectionInterval is the minimum age of the last drift detection
+	// of a stack before a new one is triggered. Defaults to
+	// DefaultDriftDetectionInterval.
+	DriftDetectionInterval time.Duration
+	InstallationName       string
 }
 
 // Main struct for this collector.
 type CloudFormation struct {
-	helper *helper
-	logger micrologger.Logger
-
-	installationName string
+	driftResources *cache.Cache[int]
+	driftTriggers  *cache.Cache[bool]
+	failureCache   *cache.Cache[stackFailure]
+	helper         *helper
+	logger         micrologger.Logger
+	tagCache       *cache.Cache[[]*cloudformation.Tag]
+
+	deletedStacksGracePeriod time.Duration
+	driftDetection           bool
+	driftDetectionInterval   time.Duration
+	installationName         string
 }
 
 // Creates a new CloudFormation metrics collector.
@@ -69,44 +100,39 @@ func NewCloudFormation(config CloudFormationConfig) (*CloudFormation, error) {
 		return nil, microerror.Maskf(invalidConfigError, "%T.InstallationName must not be empty", config)
 	}
 
-	cf := &CloudFormation{
-		helper: config.Helper,
-		logger: config.Logger,
+	if config.DriftDetectionInterval == 0 {
+		config.DriftDetectionInterval = DefaultDriftDetectionInterval
+	}
 
-		installationName: config.InstallationName,
+	cf := &CloudFormation{
+		driftResources: cache.NewCache[int](config.DriftDetectionInterval),
+		driftTriggers:  cache.NewCache[bool](config.DriftDetectionInterval),
+		failureCache:   cache.NewCache[stackFailure](time.Hour),
+		helper:         config.Helper,
+		logger:         config.Logger,
+		// Tags of stacks are set on creation and hardly change, so there is
+		// no need to describe every stack on every collection.
+		tagCache: cache.NewCache[[]*cloudformation.Tag](time.Hour),
+
+		deletedStacksGracePeriod: config.DeletedStacksGracePeriod,
+		driftDetection:           config.DriftDetection,
+		driftDetectionInterval:   config.DriftDetectionInterval,
+		installationName:         config.InstallationName,
 	}
 
 	return cf, nil
 }
 
 // Collect is the main metrics collection function.
-func (cf *CloudFormation) Collect(ch chan<- prometheus.Metric) error {
-	reconciledClusters, err := cf.helper.ListReconciledClusters()
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	awsClientsList, err := cf.helper.GetAWSClients(context.Background(), reconciledClusters)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	var g errgroup.Group
-
-	for _, item := range awsClientsList {
-		awsClients := item
-
-		g.Go(func() error {
-			err := cf.collectForAccount(ch, awsClients)
-			if err != nil {
-				return microerror.Mask(err)
-			}
-
-			return nil
-		})
-	}
+func (cf *CloudFormation) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	err := cf.helper.ForEachAccount(ctx, collectorCloudFormation, func(acc account) error {
+		err := cf.collectForAccount(ctx, ch, acc)
+		if err != nil {
+			return microerror.Mask(err)
+		}
 
-	err = g.Wait()
+		return nil
+	})
 	if err != nil {
 		return microerror.Mask(err)
 	}
@@ -117,22 +143,31 @@ func (cf *CloudFormation) Collect(ch chan<- prometheus.Metric) error {
 // Describe emits the description for the metrics collected here.
 func (cf *CloudFormation) Describe(ch chan<- *prometheus.Desc) error {
 	ch <- cloudFormationStackDesc
+	ch <- cloudFormationDriftStatusDesc
+	ch <- cloudFormationDriftedResourcesDesc
+	ch <- cloudFormationDriftCheckDesc
+	ch <- cloudFormationCreationTimeDesc
+	ch <- cloudFormationLastUpdatedTimeDesc
+	ch <- cloudFormationFailureDesc
 	return nil
 }
 
 // collectForAccount collects metrics for one AWS account.
-func (cf *CloudFormation) collectForAccount(ch chan<- prometheus.Metric, awsClients clientaws.Clients) error {
-	o, err := awsClients.CloudFormation.DescribeStacks(&cloudformation.DescribeStacksInput{})
+func (cf *CloudFormation) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+	summaries, err := listStackCandidates(ctx, acc.Clients, cf.deletedStacksGracePeriod, time.Now())
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	accountID, err := cf.helper.AWSAccountID(awsClients)
-	if err != nil {
-		return microerror.Mask(err)
-	}
+	var triggered int
+	for _, summary := range summaries {
+		tags, err := cf.stackTags(ctx, acc.Clients, summary.StackId)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		stack := stackFromSummary(summary, tags)
 
-	for _, stack := range o.Stacks {
 		var cluster, installation, name, organization, stackType string
 
 		for _, tag := range stack.Tags {
@@ -158,24 +193,160 @@ func (cf *CloudFormation) collectForAccount(ch chan<- prometheus.Metric, awsClie
 			continue
 		}
 
-		ch <- prometheus.MustNewConstMetric(
-			cloudFormationStackDesc,
-			prometheus.GaugeValue,
-			GaugeValue,
-			accountID,
+		labels := []string{
+			acc.ID,
+			acc.Region,
 			cluster,
 			*stack.StackId,
 			installation,
 			name,
 			organization,
 			stackType,
-			*stack.StackStatus,
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			cloudFormationStackDesc,
+			prometheus.GaugeValue,
+			GaugeValue,
+			append(labels, *stack.StackStatus)...,
 		)
+
+		collectStackTimes(ch, stack, labels)
+
+		// Deleted stacks are only reported with their state and times.
+		if *stack.StackStatus == cloudformation.StackStatusDeleteComplete {
+			continue
+		}
+
+		err = cf.collectFailure(ctx, ch, acc, stack, labels)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		if !cf.driftDetection {
+			continue
+		}
+
+		ok, err := cf.collectDrift(ctx, ch, acc, stack, labels, triggered < maxDriftDetectionsPerAccount)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if ok {
+			triggered++
+		}
+	}
+
+	if triggered > 0 {
+		cf.logger.Log("level", "debug", "message", fmt.Sprintf("triggered drift detection of %d stacks in account %#q and region %#q", triggered, acc.ID, acc.Region))
 	}
 
 	return nil
 }
 
+// stackTags returns the tags of the stack with the given ID.
+func (cf *CloudFormation) stackTags(ctx context.Context, awsClients clientaws.Clients, stackID *string) ([]*cloudformation.Tag, error) {
+	tags, ok := cf.tagCache.Get(aws.StringValue(stackID))
+	if ok {
+		return tags, nil
+	}
+
+	// Stacks are described by ID, which works for deleted stacks as well.
+	o, err := awsClients.CloudFormation.DescribeStacksWithContext(ctx, &cloudformation.DescribeStacksInput{
+		StackName: stackID,
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for _, s := range o.Stacks {
+		tags = s.Tags
+	}
+
+	cf.tagCache.Set(aws.StringValue(stackID), tags)
+
+	return tags, nil
+}
+
+// listStackCandidates lists the stacks in the account and region of the
+// given clients which are named like our own stacks. Deleted stacks are only
+// part of the result if they were deleted within the given grace period.
+func listStackCandidates(ctx context.Context, awsClients clientaws.Clients, gracePeriod time.Duration, now time.Time) ([]*cloudformation.StackSummary, error) {
+	i := &cloudformation.ListStacksInput{
+		StackStatusFilter: aws.StringSlice(stackStatusFilter(gracePeriod)),
+	}
+
+	var list []*cloudformation.StackSummary
+	err := awsClients.CloudFormation.ListStacksPagesWithContext(ctx, i, func(o *cloudformation.ListStacksOutput, lastPage bool) bool {
+		list = append(list, o.StackSummaries...)
+		return true
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var summaries []*cloudformation.StackSummary
+	for _, s := range list {
+		if isStackCandidate(s, gracePeriod, now) {
+			summaries = append(summaries, s)
+		}
+	}
+
+	return summaries, nil
+}
+
+// isStackCandidate returns whether the given stack is named like our own
+// stacks and, if deleted, was deleted within the given grace period.
+func isStackCandidate(summary *cloudformation.StackSummary, gracePeriod time.Duration, now time.Time) bool {
+	if !ownStackNameRegexp.MatchString(aws.StringValue(summary.StackName)) {
+		return false
+	}
+
+	if aws.StringValue(summary.StackStatus) == cloudformation.StackStatusDeleteComplete {
+		return summary.DeletionTime != nil && now.Sub(*summary.DeletionTime) < gracePeriod
+	}
+
+	return true
+}
+
+// stackFromSummary converts the given stack summary and tags into a stack, so
+// that listed stacks can be handled like described ones.
+func stackFromSummary(summary *cloudformation.StackSummary, tags []*cloudformation.Tag) *cloudformation.Stack {
+	stack := &cloudformation.Stack{
+		CreationTime:      summary.CreationTime,
+		DeletionTime:      summary.DeletionTime,
+		LastUpdatedTime:   summary.LastUpdatedTime,
+		StackId:           summary.StackId,
+		StackName:         summary.StackName,
+		StackStatus:       summary.StackStatus,
+		StackStatusReason: summary.StackStatusReason,
+		Tags:              tags,
+	}
+
+	if summary.DriftInformation != nil {
+		stack.DriftInformation = &cloudformation.StackDriftInformation{
+			LastCheckTimestamp: summary.DriftInformation.LastCheckTimestamp,
+			StackDriftStatus:   summary.DriftInformation.StackDriftStatus,
+		}
+	}
+
+	return stack
+}
+
+// stackStatusFilter returns the stack statuses listed by the collector, which
+// are all statuses except DELETE_COMPLETE unless deleted stacks are reported
+// for the given grace period.
+func stackStatusFilter(gracePeriod time.Duration) []string {
+	var statuses []string
+	for _, s := range cloudformation.StackStatus_Values() {
+		if s == cloudformation.StackStatusDeleteComplete && gracePeriod == 0 {
+			continue
+		}
+		statuses = append(statuses, s)
+	}
+
+	return statuses
+}
+
 // Check if the input stack is our own by checking the name of the stack type
 func isOwnStack(StackType string) bool {
 	return StackType == key.StackTCCP || StackType == key.StackTCCPF || StackType == key.StackTCCPI || StackType == key.StackTCNP || StackType == key.StackTCNPF
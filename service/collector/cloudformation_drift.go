@@ -0,0 +1,202 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+
+	clientaws "github.com/giantswarm/aws-collector/client/aws"
+)
+
+const (
+	labelDriftStatus = "drift_status"
+)
+
+const (
+	// DefaultDriftDetectionInterval is the default minimum age of drift
+	// detection results before the drift of a stack is detected again.
+	DefaultDriftDetectionInterval = 6 * time.Hour
+
+	// maxDriftDetectionsPerAccount limits the number of drift detections
+	// triggered per account and region in a single collection, so that
+	// enabling drift detection does not trigger it for all stacks at once.
+	maxDriftDetectionsPerAccount = 5
+)
+
+var (
+	cloudFormationDriftStatusDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemCloudFormation, "drift_status"),
+		"Gauge about the drift status of Cloud Formation stacks as of the last drift detection. Always 1, the status is given by the drift_status label.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelID,
+			labelInstallation,
+			labelName,
+			labelOrganization,
+			labelStackType,
+			labelDriftStatus,
+		},
+		nil,
+	)
+	cloudFormationDriftedResourcesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemCloudFormation, "drifted_resources_count"),
+		"Gauge about the number of modified or deleted resources of Cloud Formation stacks as of the last drift detection.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelID,
+			labelInstallation,
+			labelName,
+			labelOrganization,
+			labelStackType,
+		},
+		nil,
+	)
+	cloudFormationDriftCheckDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemCloudFormation, "drift_last_check_timestamp_seconds"),
+		"Gauge about the time of the last drift detection of Cloud Formation stacks as Unix timestamp in seconds.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelID,
+			labelInstallation,
+			labelName,
+			labelOrganization,
+			labelStackType,
+		},
+		nil,
+	)
+)
+
+var (
+	// driftableStackStatuses are the statuses of stacks DetectStackDrift
+	// accepts. Stacks in any other status fail with a validation error.
+	driftableStackStatuses = map[string]bool{
+		cloudformation.StackStatusCreateComplete:         true,
+		cloudformation.StackStatusUpdateComplete:         true,
+		cloudformation.StackStatusUpdateRollbackComplete: true,
+		cloudformation.StackStatusUpdateRollbackFailed:   true,
+	}
+)
+
+// collectDrift emits the drift metrics of the given stack and triggers a new
+// drift detection in case the last one is older than the drift detection
+// interval. Drift detection runs asynchronously in AWS, so its results are
+// reported by later collections. It returns whether a drift detection was
+// triggered.
+func (cf *CloudFormation) collectDrift(ctx context.Context, ch chan<- prometheus.Metric, acc account, stack *cloudformation.Stack, labels []string, triggerAllowed bool) (bool, error) {
+	var status string
+	var lastCheck *time.Time
+	if stack.DriftInformation != nil {
+		status = aws.StringValue(stack.DriftInformation.StackDriftStatus)
+		lastCheck = stack.DriftInformation.LastCheckTimestamp
+	}
+
+	var triggered bool
+	if triggerAllowed && needsDriftDetection(stack, lastCheck, cf.driftDetectionInterval, time.Now()) {
+		// Detections are only triggered once per interval, even when their
+		// results did not arrive yet.
+		_, ok := cf.driftTriggers.Get(aws.StringValue(stack.StackId))
+		if !ok {
+			_, err := acc.Clients.CloudFormation.DetectStackDriftWithContext(ctx, &cloudformation.DetectStackDriftInput{
+				StackName: stack.StackId,
+			})
+			if err != nil {
+				return false, microerror.Mask(err)
+			}
+
+			cf.driftTriggers.Set(aws.StringValue(stack.StackId), true)
+			triggered = true
+		}
+	}
+
+	if lastCheck == nil {
+		return triggered, nil
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		cloudFormationDriftStatusDesc,
+		prometheus.GaugeValue,
+		GaugeValue,
+		append(labels, status)...,
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		cloudFormationDriftCheckDesc,
+		prometheus.GaugeValue,
+		float64(lastCheck.Unix()),
+		labels...,
+	)
+
+	var drifted int
+	if status == cloudformation.StackDriftStatusDrifted {
+		// The results of a drift detection do not change, so they are cached
+		// for the time of the check.
+		cacheKey := fmt.Sprintf("%s/%d", aws.StringValue(stack.StackId), lastCheck.Unix())
+
+		var ok bool
+		drifted, ok = cf.driftResources.Get(cacheKey)
+		if !ok {
+			var err error
+			drifted, err = countDriftedResources(ctx, acc.Clients, stack.StackId)
+			if err != nil {
+				return triggered, microerror.Mask(err)
+			}
+
+			cf.driftResources.Set(cacheKey, drifted)
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		cloudFormationDriftedResourcesDesc,
+		prometheus.GaugeValue,
+		float64(drifted),
+		labels...,
+	)
+
+	return triggered, nil
+}
+
+// countDriftedResources returns the number of resources of the given stack
+// which were modified or deleted as of the last drift detection.
+func countDriftedResources(ctx context.Context, awsClients clientaws.Clients, stackID *string) (int, error) {
+	i := &cloudformation.DescribeStackResourceDriftsInput{
+		StackName: stackID,
+		StackResourceDriftStatusFilters: aws.StringSlice([]string{
+			cloudformation.StackResourceDriftStatusModified,
+			cloudformation.StackResourceDriftStatusDeleted,
+		}),
+	}
+
+	var drifts []*cloudformation.StackResourceDrift
+	err := awsClients.CloudFormation.DescribeStackResourceDriftsPagesWithContext(ctx, i, func(o *cloudformation.DescribeStackResourceDriftsOutput, lastPage bool) bool {
+		drifts = append(drifts, o.StackResourceDrifts...)
+		return true
+	})
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return len(drifts), nil
+}
+
+// needsDriftDetection returns whether the drift of the given stack should be
+// detected, which is the case if it was never detected or the last detection
+// is older than the given interval. Drift can only be detected for stacks in
+// one of the driftableStackStatuses.
+func needsDriftDetection(stack *cloudformation.Stack, lastCheck *time.Time, interval time.Duration, now time.Time) bool {
+	if !driftableStackStatuses[aws.StringValue(stack.StackStatus)] {
+		return false
+	}
+
+	return lastCheck == nil || now.Sub(*lastCheck) >= interval
+}
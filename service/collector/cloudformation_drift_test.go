@@ -0,0 +1,110 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestNeedsDriftDetection(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		status    string
+		lastCheck *time.Time
+
+		expectedNeedsDetection bool
+	}{
+		{
+			name:   "case 0: drift of stack never detected",
+			status: cloudformation.StackStatusCreateComplete,
+
+			expectedNeedsDetection: true,
+		},
+		{
+			name:      "case 1: drift detected recently",
+			status:    cloudformation.StackStatusUpdateComplete,
+			lastCheck: aws.Time(now.Add(-time.Hour)),
+
+			expectedNeedsDetection: false,
+		},
+		{
+			name:      "case 2: drift detection older than interval",
+			status:    cloudformation.StackStatusUpdateComplete,
+			lastCheck: aws.Time(now.Add(-6 * time.Hour)),
+
+			expectedNeedsDetection: true,
+		},
+		{
+			name:   "case 3: stack being updated",
+			status: cloudformation.StackStatusUpdateInProgress,
+
+			expectedNeedsDetection: false,
+		},
+		{
+			name:   "case 4: stack being rolled back",
+			status: cloudformation.StackStatusUpdateRollbackCompleteCleanupInProgress,
+
+			expectedNeedsDetection: false,
+		},
+		{
+			name:   "case 5: stack failed to be created",
+			status: cloudformation.StackStatusCreateFailed,
+
+			expectedNeedsDetection: false,
+		},
+		{
+			name:   "case 6: stack rolled back after failed creation",
+			status: cloudformation.StackStatusRollbackComplete,
+
+			expectedNeedsDetection: false,
+		},
+		{
+			name:   "case 7: stack failed to be rolled back",
+			status: cloudformation.StackStatusRollbackFailed,
+
+			expectedNeedsDetection: false,
+		},
+		{
+			name:   "case 8: stack failed to be deleted",
+			status: cloudformation.StackStatusDeleteFailed,
+
+			expectedNeedsDetection: false,
+		},
+		{
+			name:   "case 9: stack rolled back after failed import",
+			status: cloudformation.StackStatusImportRollbackComplete,
+
+			expectedNeedsDetection: false,
+		},
+		{
+			name:   "case 10: stack rolled back after failed update",
+			status: cloudformation.StackStatusUpdateRollbackComplete,
+
+			expectedNeedsDetection: true,
+		},
+		{
+			name:   "case 11: stack failed to be rolled back after failed update",
+			status: cloudformation.StackStatusUpdateRollbackFailed,
+
+			expectedNeedsDetection: true,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			stack := &cloudformation.Stack{
+				StackStatus: aws.String(tc.status),
+			}
+
+			needsDetection := needsDriftDetection(stack, tc.lastCheck, 6*time.Hour, now)
+			if needsDetection != tc.expectedNeedsDetection {
+				t.Fatalf("expected %t, got %t", tc.expectedNeedsDetection, needsDetection)
+			}
+		})
+	}
+}
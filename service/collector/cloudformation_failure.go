@@ -0,0 +1,308 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+
+	clientaws "github.com/giantswarm/aws-collector/client/aws"
+)
+
+const (
+	labelResourceType = "resource_type"
+)
+
+const (
+	stackFailureReasonAccessDenied         = "access_denied"
+	stackFailureReasonAlreadyExists        = "already_exists"
+	stackFailureReasonCancelled            = "cancelled"
+	stackFailureReasonDependencyViolation  = "dependency_violation"
+	stackFailureReasonInsufficientCapacity = "insufficient_capacity"
+	stackFailureReasonInvalidParameter     = "invalid_parameter"
+	stackFailureReasonLimitExceeded        = "limit_exceeded"
+	stackFailureReasonNotFound             = "not_found"
+	stackFailureReasonOther                = "other"
+	stackFailureReasonThrottling           = "throttling"
+	stackFailureReasonTimeout              = "timeout"
+
+	// maxStackEventPages limits the number of stack event pages described
+	// when looking for the start of the last operation of a failed stack.
+	maxStackEventPages = 5
+)
+
+var (
+	cloudFormationCreationTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemCloudFormation, "creation_timestamp_seconds"),
+		"Gauge about the creation time of Cloud Formation stacks as Unix timestamp in seconds.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelID,
+			labelInstallation,
+			labelName,
+			labelOrganization,
+			labelStackType,
+		},
+		nil,
+	)
+	cloudFormationLastUpdatedTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemCloudFormation, "last_updated_timestamp_seconds"),
+		"Gauge about the time Cloud Formation stacks were last updated, or created if never updated, as Unix timestamp in seconds. This is the time the stack entered its current state.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelID,
+			labelInstallation,
+			labelName,
+			labelOrganization,
+			labelStackType,
+			labelState,
+		},
+		nil,
+	)
+	cloudFormationFailureDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemCloudFormation, "failure_reason"),
+		"Gauge about the reason of the first failure of the last operation of failed Cloud Formation stacks. Always 1, the reason and the type of the failing resource are given by labels.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelID,
+			labelInstallation,
+			labelName,
+			labelOrganization,
+			labelStackType,
+			labelReason,
+			labelResourceType,
+		},
+		nil,
+	)
+)
+
+var (
+	// stackFailureReasons classify the status reasons of failed stack
+	// events. They are matched in order against the lower-cased status
+	// reason.
+	stackFailureReasons = []struct {
+		Reason   string
+		Patterns []string
+	}{
+		{
+			Reason:   stackFailureReasonCancelled,
+			Patterns: []string{"cancelled", "canceled"},
+		},
+		{
+			Reason:   stackFailureReasonThrottling,
+			Patterns: []string{"rate exceeded", "throttling"},
+		},
+		{
+			Reason:   stackFailureReasonLimitExceeded,
+			Patterns: []string{"limitexceeded", "limit exceeded", "quota", "maximum number"},
+		},
+		{
+			Reason:   stackFailureReasonInsufficientCapacity,
+			Patterns: []string{"insufficientinstancecapacity", "insufficient capacity", "do not have sufficient"},
+		},
+		{
+			Reason:   stackFailureReasonAccessDenied,
+			Patterns: []string{"accessdenied", "access denied", "not authorized", "unauthorizedoperation"},
+		},
+		{
+			Reason:   stackFailureReasonAlreadyExists,
+			Patterns: []string{"already exists"},
+		},
+		{
+			Reason:   stackFailureReasonDependencyViolation,
+			Patterns: []string{"dependencyviolation", "has dependencies", "dependent object", "has a dependent"},
+		},
+		{
+			Reason:   stackFailureReasonNotFound,
+			Patterns: []string{"does not exist", "notfound", "not found"},
+		},
+		{
+			Reason:   stackFailureReasonTimeout,
+			Patterns: []string{"timed out", "timeout", "stabilize"},
+		},
+		{
+			Reason:   stackFailureReasonInvalidParameter,
+			Patterns: []string{"invalidparameter", "validation", "invalid"},
+		},
+	}
+
+	// stackOperationStartStatuses are the statuses of the stack event which
+	// starts an operation. Rollbacks belong to the operation they roll back.
+	stackOperationStartStatuses = map[string]bool{
+		cloudformation.ResourceStatusCreateInProgress: true,
+		cloudformation.ResourceStatusDeleteInProgress: true,
+		cloudformation.ResourceStatusImportInProgress: true,
+		cloudformation.ResourceStatusUpdateInProgress: true,
+	}
+)
+
+type stackFailure struct {
+	Reason       string
+	ResourceType string
+}
+
+// collectStackTimes emits the creation and last update time of the given stack.
+func collectStackTimes(ch chan<- prometheus.Metric, stack *cloudformation.Stack, labels []string) {
+	if stack.CreationTime == nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		cloudFormationCreationTimeDesc,
+		prometheus.GaugeValue,
+		float64(stack.CreationTime.Unix()),
+		labels...,
+	)
+
+	ch <- prometheus.MustNewConstMetric(
+		cloudFormationLastUpdatedTimeDesc,
+		prometheus.GaugeValue,
+		float64(stackLastUpdatedTime(stack).Unix()),
+		append(labels, aws.StringValue(stack.StackStatus))...,
+	)
+}
+
+// collectFailure emits the failure reason of the given stack in case it is
+// failed.
+func (cf *CloudFormation) collectFailure(ctx context.Context, ch chan<- prometheus.Metric, acc account, stack *cloudformation.Stack, labels []string) error {
+	if !strings.HasSuffix(aws.StringValue(stack.StackStatus), "_FAILED") {
+		return nil
+	}
+
+	// The events of a failed stack only change with its next operation, which
+	// changes its status or last update time.
+	cacheKey := fmt.Sprintf("%s/%s/%d", aws.StringValue(stack.StackId), aws.StringValue(stack.StackStatus), stackLastUpdatedTime(stack).Unix())
+
+	failure, ok := cf.failureCache.Get(cacheKey)
+	if !ok {
+		events, err := lastOperationEvents(ctx, acc.Clients, stack.StackId)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		failure = firstStackFailure(stack, events)
+		cf.failureCache.Set(cacheKey, failure)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		cloudFormationFailureDesc,
+		prometheus.GaugeValue,
+		GaugeValue,
+		append(labels, failure.Reason, failure.ResourceType)...,
+	)
+
+	return nil
+}
+
+// lastOperationEvents returns the events of the given stack, newest first,
+// up to the event starting its last operation.
+func lastOperationEvents(ctx context.Context, awsClients clientaws.Clients, stackID *string) ([]*cloudformation.StackEvent, error) {
+	var events []*cloudformation.StackEvent
+	var pages int
+
+	i := &cloudformation.DescribeStackEventsInput{
+		StackName: stackID,
+	}
+
+	err := awsClients.CloudFormation.DescribeStackEventsPagesWithContext(ctx, i, func(o *cloudformation.DescribeStackEventsOutput, lastPage bool) bool {
+		pages++
+
+		for _, e := range o.StackEvents {
+			events = append(events, e)
+
+			if isStackOperationStart(aws.StringValue(stackID), e) {
+				return false
+			}
+		}
+
+		return pages < maxStackEventPages
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return events, nil
+}
+
+// firstStackFailure returns the classified reason and resource type of the
+// first failed event of the last operation of the given stack. Events are
+// expected newest first. Failures of resources take precedence over the
+// failure of the stack itself, which only summarizes the failed resources.
+func firstStackFailure(stack *cloudformation.Stack, events []*cloudformation.StackEvent) stackFailure {
+	var resourceFailure, stackFailureEvent *cloudformation.StackEvent
+	for _, e := range events {
+		if isStackOperationStart(aws.StringValue(stack.StackId), e) {
+			break
+		}
+		if !strings.HasSuffix(aws.StringValue(e.ResourceStatus), "_FAILED") {
+			continue
+		}
+
+		if aws.StringValue(e.PhysicalResourceId) == aws.StringValue(stack.StackId) {
+			stackFailureEvent = e
+		} else {
+			resourceFailure = e
+		}
+	}
+
+	switch {
+	case resourceFailure != nil:
+		return stackFailure{
+			Reason:       stackFailureReason(aws.StringValue(resourceFailure.ResourceStatusReason)),
+			ResourceType: aws.StringValue(resourceFailure.ResourceType),
+		}
+	case stackFailureEvent != nil:
+		return stackFailure{
+			Reason:       stackFailureReason(aws.StringValue(stackFailureEvent.ResourceStatusReason)),
+			ResourceType: aws.StringValue(stackFailureEvent.ResourceType),
+		}
+	default:
+		return stackFailure{
+			Reason:       stackFailureReason(aws.StringValue(stack.StackStatusReason)),
+			ResourceType: "AWS::CloudFormation::Stack",
+		}
+	}
+}
+
+// isStackOperationStart returns whether the given event starts an operation
+// of the stack with the given ID.
+func isStackOperationStart(stackID string, e *cloudformation.StackEvent) bool {
+	return aws.StringValue(e.PhysicalResourceId) == stackID && stackOperationStartStatuses[aws.StringValue(e.ResourceStatus)]
+}
+
+// stackFailureReason classifies the given status reason of a failed stack
+// event into one of the stackFailureReasons, or other if none matches.
+func stackFailureReason(statusReason string) string {
+	r := strings.ToLower(statusReason)
+
+	for _, f := range stackFailureReasons {
+		for _, p := range f.Patterns {
+			if strings.Contains(r, p) {
+				return f.Reason
+			}
+		}
+	}
+
+	return stackFailureReasonOther
+}
+
+// stackLastUpdatedTime returns the time the given stack was last updated, or
+// created if it was never updated.
+func stackLastUpdatedTime(stack *cloudformation.Stack) time.Time {
+	if stack.LastUpdatedTime != nil {
+		return *stack.LastUpdatedTime
+	}
+
+	return aws.TimeValue(stack.CreationTime)
+}
@@ -0,0 +1,186 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestFirstStackFailure(t *testing.T) {
+	stackID := "arn:aws:cloudformation:eu-west-1:123456789012:stack/cluster-a1b2c-tcnp-d3e4f/1"
+
+	testCases := []struct {
+		name   string
+		stack  *cloudformation.Stack
+		events []*cloudformation.StackEvent
+
+		expectedFailure stackFailure
+	}{
+		{
+			name: "case 0: first failed resource of the last operation is used",
+			stack: &cloudformation.Stack{
+				StackId:     aws.String(stackID),
+				StackStatus: aws.String(cloudformation.StackStatusUpdateRollbackFailed),
+			},
+			events: []*cloudformation.StackEvent{
+				{
+					PhysicalResourceId:   aws.String(stackID),
+					ResourceStatus:       aws.String(cloudformation.ResourceStatusUpdateFailed),
+					ResourceStatusReason: aws.String("The following resource(s) failed to update: [LaunchTemplate]"),
+					ResourceType:         aws.String("AWS::CloudFormation::Stack"),
+				},
+				{
+					PhysicalResourceId:   aws.String("sg-1"),
+					ResourceStatus:       aws.String(cloudformation.ResourceStatusDeleteFailed),
+					ResourceStatusReason: aws.String("resource sg-1 has a dependent object"),
+					ResourceType:         aws.String("AWS::EC2::SecurityGroup"),
+				},
+				{
+					PhysicalResourceId: aws.String(stackID),
+					ResourceStatus:     aws.String("UPDATE_ROLLBACK_IN_PROGRESS"),
+					ResourceType:       aws.String("AWS::CloudFormation::Stack"),
+				},
+				{
+					PhysicalResourceId:   aws.String("lt-1"),
+					ResourceStatus:       aws.String(cloudformation.ResourceStatusUpdateFailed),
+					ResourceStatusReason: aws.String("Resource update cancelled"),
+					ResourceType:         aws.String("AWS::EC2::LaunchTemplate"),
+				},
+				{
+					PhysicalResourceId:   aws.String("asg-1"),
+					ResourceStatus:       aws.String(cloudformation.ResourceStatusUpdateFailed),
+					ResourceStatusReason: aws.String("You have requested more vCPU capacity than your current vCPU limit of 64 allows. (Service: AmazonEC2; Status Code: 400; Error Code: VcpuLimitExceeded)"),
+					ResourceType:         aws.String("AWS::AutoScaling::AutoScalingGroup"),
+				},
+				{
+					PhysicalResourceId:   aws.String(stackID),
+					ResourceStatus:       aws.String(cloudformation.ResourceStatusUpdateInProgress),
+					ResourceStatusReason: aws.String("User Initiated"),
+					ResourceType:         aws.String("AWS::CloudFormation::Stack"),
+				},
+				{
+					PhysicalResourceId:   aws.String("vpc-1"),
+					ResourceStatus:       aws.String(cloudformation.ResourceStatusCreateFailed),
+					ResourceStatusReason: aws.String("The maximum number of VPCs has been reached."),
+					ResourceType:         aws.String("AWS::EC2::VPC"),
+				},
+			},
+
+			expectedFailure: stackFailure{
+				Reason:       stackFailureReasonLimitExceeded,
+				ResourceType: "AWS::AutoScaling::AutoScalingGroup",
+			},
+		},
+		{
+			name: "case 1: failure of the stack itself is used without failed resources",
+			stack: &cloudformation.Stack{
+				StackId:     aws.String(stackID),
+				StackStatus: aws.String(cloudformation.StackStatusCreateFailed),
+			},
+			events: []*cloudformation.StackEvent{
+				{
+					PhysicalResourceId:   aws.String(stackID),
+					ResourceStatus:       aws.String(cloudformation.ResourceStatusCreateFailed),
+					ResourceStatusReason: aws.String("Template error: instance of Fn::GetAtt references undefined resource"),
+					ResourceType:         aws.String("AWS::CloudFormation::Stack"),
+				},
+				{
+					PhysicalResourceId:   aws.String(stackID),
+					ResourceStatus:       aws.String(cloudformation.ResourceStatusCreateInProgress),
+					ResourceStatusReason: aws.String("User Initiated"),
+					ResourceType:         aws.String("AWS::CloudFormation::Stack"),
+				},
+			},
+
+			expectedFailure: stackFailure{
+				Reason:       stackFailureReasonOther,
+				ResourceType: "AWS::CloudFormation::Stack",
+			},
+		},
+		{
+			name: "case 2: stack status reason is used without events",
+			stack: &cloudformation.Stack{
+				StackId:           aws.String(stackID),
+				StackStatus:       aws.String(cloudformation.StackStatusDeleteFailed),
+				StackStatusReason: aws.String("Access Denied"),
+			},
+
+			expectedFailure: stackFailure{
+				Reason:       stackFailureReasonAccessDenied,
+				ResourceType: "AWS::CloudFormation::Stack",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			failure := firstStackFailure(tc.stack, tc.events)
+			if failure != tc.expectedFailure {
+				t.Fatalf("expected %#v, got %#v", tc.expectedFailure, failure)
+			}
+		})
+	}
+}
+
+func TestStackFailureReason(t *testing.T) {
+	testCases := []struct {
+		name         string
+		statusReason string
+
+		expectedReason string
+	}{
+		{
+			name:         "case 0: resource already exists",
+			statusReason: "cluster-a1b2c-master already exists in stack arn:aws:cloudformation:eu-west-1:123456789012:stack/cluster-a1b2c-tccp/1",
+
+			expectedReason: stackFailureReasonAlreadyExists,
+		},
+		{
+			name:         "case 1: insufficient capacity",
+			statusReason: "We currently do not have sufficient m5.xlarge capacity in the Availability Zone you requested (eu-west-1a).",
+
+			expectedReason: stackFailureReasonInsufficientCapacity,
+		},
+		{
+			name:         "case 2: dependency violation",
+			statusReason: "The vpc 'vpc-1' has dependencies and cannot be deleted. (Service: AmazonEC2; Status Code: 400; Error Code: DependencyViolation)",
+
+			expectedReason: stackFailureReasonDependencyViolation,
+		},
+		{
+			name:         "case 3: throttling",
+			statusReason: "Rate exceeded (Service: AmazonEC2; Status Code: 400; Error Code: Throttling)",
+
+			expectedReason: stackFailureReasonThrottling,
+		},
+		{
+			name:         "case 4: resource not found",
+			statusReason: "The image id '[ami-1]' does not exist (Service: AmazonEC2; Status Code: 400; Error Code: InvalidAMIID.NotFound)",
+
+			expectedReason: stackFailureReasonNotFound,
+		},
+		{
+			name:         "case 5: unknown reason",
+			statusReason: "Exceeded attempts to wait",
+
+			expectedReason: stackFailureReasonOther,
+		},
+		{
+			name:         "case 6: empty reason",
+			statusReason: "",
+
+			expectedReason: stackFailureReasonOther,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			reason := stackFailureReason(tc.statusReason)
+			if reason != tc.expectedReason {
+				t.Fatalf("expected %#v, got %#v", tc.expectedReason, reason)
+			}
+		})
+	}
+}
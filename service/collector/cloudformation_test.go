@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+)
+
+func TestIsStackCandidate(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name        string
+		summary     *cloudformation.StackSummary
+		gracePeriod time.Duration
+
+		expectedCandidate bool
+	}{
+		{
+			name: "case 0: control plane stack",
+			summary: &cloudformation.StackSummary{
+				StackName:   aws.String("cluster-a1b2c-tccp"),
+				StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			},
+
+			expectedCandidate: true,
+		},
+		{
+			name: "case 1: node pool stack",
+			summary: &cloudformation.StackSummary{
+				StackName:   aws.String("cluster-a1b2c-tcnp-d3e4f"),
+				StackStatus: aws.String(cloudformation.StackStatusUpdateInProgress),
+			},
+
+			expectedCandidate: true,
+		},
+		{
+			name: "case 2: unrelated stack",
+			summary: &cloudformation.StackSummary{
+				StackName:   aws.String("eksctl-prod-cluster"),
+				StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+			},
+
+			expectedCandidate: false,
+		},
+		{
+			name: "case 3: stack deleted within grace period",
+			summary: &cloudformation.StackSummary{
+				DeletionTime: aws.Time(now.Add(-30 * time.Minute)),
+				StackName:    aws.String("cluster-a1b2c-tcnpf-d3e4f"),
+				StackStatus:  aws.String(cloudformation.StackStatusDeleteComplete),
+			},
+			gracePeriod: time.Hour,
+
+			expectedCandidate: true,
+		},
+		{
+			name: "case 4: stack deleted before grace period",
+			summary: &cloudformation.StackSummary{
+				DeletionTime: aws.Time(now.Add(-2 * time.Hour)),
+				StackName:    aws.String("cluster-a1b2c-tcnpf-d3e4f"),
+				StackStatus:  aws.String(cloudformation.StackStatusDeleteComplete),
+			},
+			gracePeriod: time.Hour,
+
+			expectedCandidate: false,
+		},
+		{
+			name: "case 5: deleted stack without grace period",
+			summary: &cloudformation.StackSummary{
+				DeletionTime: aws.Time(now),
+				StackName:    aws.String("cluster-a1b2c-tccpi"),
+				StackStatus:  aws.String(cloudformation.StackStatusDeleteComplete),
+			},
+
+			expectedCandidate: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			candidate := isStackCandidate(tc.summary, tc.gracePeriod, now)
+			if candidate != tc.expectedCandidate {
+				t.Fatalf("expected %t, got %t", tc.expectedCandidate, candidate)
+			}
+		})
+	}
+}
+
+func TestStackStatusFilter(t *testing.T) {
+	testCases := []struct {
+		name        string
+		gracePeriod time.Duration
+
+		expectedDeleteComplete bool
+	}{
+		{
+			name: "case 0: deleted stacks are not listed without grace period",
+
+			expectedDeleteComplete: false,
+		},
+		{
+			name:        "case 1: deleted stacks are listed with grace period",
+			gracePeriod: time.Hour,
+
+			expectedDeleteComplete: true,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			statuses := stackStatusFilter(tc.gracePeriod)
+
+			var deleteComplete, createComplete bool
+			for _, s := range statuses {
+				switch s {
+				case cloudformation.StackStatusCreateComplete:
+					createComplete = true
+				case cloudformation.StackStatusDeleteComplete:
+					deleteComplete = true
+				}
+			}
+
+			if !createComplete {
+				t.Fatalf("expected %#q to be listed", cloudformation.StackStatusCreateComplete)
+			}
+			if deleteComplete != tc.expectedDeleteComplete {
+				t.Fatalf("expected %#q to be listed %t, got %t", cloudformation.StackStatusDeleteComplete, tc.expectedDeleteComplete, deleteComplete)
+			}
+		})
+	}
+}
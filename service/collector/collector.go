@@ -7,6 +7,7 @@ const (
 
 const (
 	tagCluster      = "giantswarm.io/cluster"
+	tagMachinePool  = "giantswarm.io/machine-pool"
 	tagName         = "Name"
 	tagOrganization = "giantswarm.io/organization"
 	tagStackName    = "aws:cloudformation:stack-name"
@@ -17,7 +18,31 @@ const (
 	labelAccount      = "account"
 	labelAccountID    = "account_id"
 	labelCluster      = "cluster_id"
+	labelCollector    = "collector"
+	labelDiscovery    = "discovery"
 	labelName         = "name"
 	labelInstallation = "installation"
 	labelOrganization = "organization"
+	labelReason       = "reason"
+	labelRegion       = "region"
+)
+
+// Collector names are used to identify collectors in self-observability
+// metrics and logs.
+const (
+	collectorASG            = "asg"
+	collectorCloudFormation = "cloudformation"
+	collectorEBS            = "ebs"
+	collectorEC2Instances   = "ec2instances"
+	collectorEIP            = "eip"
+	collectorELB            = "elb"
+	collectorELBv2          = "elbv2"
+	collectorNAT            = "nat"
+	collectorNATGateway     = "natgateway"
+	collectorRelease        = "release"
+	collectorServiceQuota   = "servicequota"
+	collectorSubnet         = "subnet"
+	collectorTrustedAdvisor = "trustedadvisor"
+	collectorUpdate         = "update"
+	collectorVPC            = "vpc"
 )
@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	infrastructurev1alpha3 "github.com/giantswarm/apiextensions/v6/pkg/apis/infrastructure/v1alpha3"
+	"github.com/giantswarm/k8sclient/v7/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/giantswarm/aws-collector/service/controller/key"
+	"github.com/giantswarm/aws-collector/service/internal/credential"
+)
+
+// target describes how to access the AWS account of a cluster.
+type target struct {
+	// RoleARN is the IAM role assumed to access the account. Empty means the
+	// account of the host credentials is used.
+	RoleARN string
+	// ExternalID is passed when assuming RoleARN, if set.
+	ExternalID string
+	// Region is the region the cluster is located in. Empty means the region
+	// of the control plane.
+	Region string
+}
+
+// clusterDiscovery finds the clusters to collect metrics for and returns how
+// to access their AWS accounts. Multiple clusters may share the same target.
+type clusterDiscovery interface {
+	// Name identifies the discovery in logs and metrics.
+	Name() string
+	Targets(ctx context.Context) ([]target, error)
+}
+
+type giantSwarmDiscoveryConfig struct {
+	Clients k8sclient.Interface
+	Logger  micrologger.Logger
+}
+
+// giantSwarmDiscovery finds clusters based on the Giant Swarm
+// infrastructure.giantswarm.io AWSCluster CRs and their credential secrets.
+type giantSwarmDiscovery struct {
+	clients k8sclient.Interface
+	logger  micrologger.Logger
+}
+
+func newGiantSwarmDiscovery(config giantSwarmDiscoveryConfig) (*giantSwarmDiscovery, error) {
+	if config.Clients == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Clients must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	d := &giantSwarmDiscovery{
+		clients: config.Clients,
+		logger:  config.Logger,
+	}
+
+	return d, nil
+}
+
+func (d *giantSwarmDiscovery) Name() string {
+	return "giantswarm"
+}
+
+// Targets returns the ARNs of the credential secrets of all clusters. Clusters
+// without credential secret use the default credential secret, which is also
+// always returned for the control plane region in case it exists.
+func (d *giantSwarmDiscovery) Targets(ctx context.Context) ([]target, error) {
+	clusterList := &infrastructurev1alpha3.AWSClusterList{}
+	err := d.clients.CtrlClient().List(ctx, clusterList)
+	if meta.IsNoMatchError(err) {
+		// Installations purely based on Cluster API do not have the CRD.
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var targets []target
+
+	// Ensure we check the default guest account for old cluster not having credential.
+	defaultARN, err := credential.GetDefaultARN(ctx, d.clients.K8sClient())
+	if apierrors.IsNotFound(err) {
+		d.logger.Log("level", "debug", "message", "default credential not found")
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	} else {
+		targets = append(targets, target{RoleARN: defaultARN})
+	}
+
+	for _, clusterCR := range clusterList.Items {
+		arn, err := credential.GetARN(ctx, d.clients.K8sClient(), clusterCR)
+		// Collect as many ARNs as possible in order to provide most metrics.
+		// Old clusters which do not have credential use the default one.
+		if credential.IsCredentialNameEmptyError(err) || credential.IsCredentialNamespaceEmptyError(err) {
+			if defaultARN == "" {
+				continue
+			}
+			arn = defaultARN
+		} else if err != nil {
+			d.logger.Log("level", "warning", "message", fmt.Sprintf("failed getting credential of cluster %#q", clusterCR.Name), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		targets = append(targets, target{RoleARN: arn, Region: key.Region(clusterCR)})
+	}
+
+	return targets, nil
+}
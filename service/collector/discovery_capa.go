@@ -0,0 +1,185 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	capaIdentityKindController = "AWSClusterControllerIdentity"
+	capaIdentityKindRole       = "AWSClusterRoleIdentity"
+)
+
+var (
+	// CAPA resources are read as unstructured objects, so that the collector
+	// does not depend on the Cluster API provider's Go module.
+	capaClusterListGVK = schema.GroupVersionKind{
+		Group:   "infrastructure.cluster.x-k8s.io",
+		Version: "v1beta2",
+		Kind:    "AWSClusterList",
+	}
+	capaManagedControlPlaneListGVK = schema.GroupVersionKind{
+		Group:   "controlplane.cluster.x-k8s.io",
+		Version: "v1beta2",
+		Kind:    "AWSManagedControlPlaneList",
+	}
+	capaRoleIdentityListGVK = schema.GroupVersionKind{
+		Group:   "infrastructure.cluster.x-k8s.io",
+		Version: "v1beta2",
+		Kind:    "AWSClusterRoleIdentityList",
+	}
+)
+
+type capaDiscoveryConfig struct {
+	Client client.Client
+	Logger micrologger.Logger
+}
+
+// capaDiscovery finds clusters based on the Cluster API Provider AWS
+// AWSCluster and AWSManagedControlPlane CRs. The accounts of the clusters are
+// accessed through the roles of their AWSClusterRoleIdentity. The collector
+// assumes these roles directly with its own credentials, so source identities
+// configured for role chaining are not followed. Clusters using the controller
+// identity are located in the account of the host credentials.
+type capaDiscovery struct {
+	client client.Client
+	logger micrologger.Logger
+}
+
+func newCAPADiscovery(config capaDiscoveryConfig) (*capaDiscovery, error) {
+	if config.Client == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Client must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	d := &capaDiscovery{
+		client: config.Client,
+		logger: config.Logger,
+	}
+
+	return d, nil
+}
+
+func (d *capaDiscovery) Name() string {
+	return "capa"
+}
+
+// Targets returns the role and region of every CAPA cluster. Clusters whose
+// identity cannot be resolved are logged and skipped.
+func (d *capaDiscovery) Targets(ctx context.Context) ([]target, error) {
+	identities, err := d.roleIdentities(ctx)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var targets []target
+	for _, gvk := range []schema.GroupVersionKind{capaClusterListGVK, capaManagedControlPlaneListGVK} {
+		items, err := d.list(ctx, gvk)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, item := range items {
+			t, err := capaTarget(item, identities)
+			if err != nil {
+				d.logger.Log("level", "warning", "message", fmt.Sprintf("failed resolving identity of %s %#q", item.GetKind(), item.GetNamespace()+"/"+item.GetName()), "stack", fmt.Sprintf("%#v", err))
+				continue
+			}
+
+			targets = append(targets, t)
+		}
+	}
+
+	return targets, nil
+}
+
+// list returns all objects of the given list kind. Missing CRDs are not
+// considered an error, as not every installation runs every CAPA flavour.
+// Neither is missing permission to list them, as the RBAC rules for CAPA
+// resources may not be deployed yet.
+func (d *capaDiscovery) list(ctx context.Context, gvk schema.GroupVersionKind) ([]unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk)
+
+	err := d.client.List(ctx, list)
+	if meta.IsNoMatchError(err) {
+		return nil, nil
+	} else if apierrors.IsForbidden(err) {
+		d.logger.Log("level", "warning", "message", fmt.Sprintf("not allowed to list %s", gvk.Kind), "stack", fmt.Sprintf("%#v", err))
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return list.Items, nil
+}
+
+// roleIdentities returns the targets of all AWSClusterRoleIdentity objects
+// keyed by name. The returned targets do not have a region.
+func (d *capaDiscovery) roleIdentities(ctx context.Context) (map[string]target, error) {
+	items, err := d.list(ctx, capaRoleIdentityListGVK)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	identities := make(map[string]target, len(items))
+	for _, item := range items {
+		roleARN, _, err := unstructured.NestedString(item.Object, "spec", "roleARN")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		externalID, _, err := unstructured.NestedString(item.Object, "spec", "externalID")
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		identities[item.GetName()] = target{RoleARN: roleARN, ExternalID: externalID}
+	}
+
+	return identities, nil
+}
+
+// capaTarget returns the target of the given AWSCluster or
+// AWSManagedControlPlane, using the given role identities keyed by name.
+func capaTarget(obj unstructured.Unstructured, identities map[string]target) (target, error) {
+	region, _, err := unstructured.NestedString(obj.Object, "spec", "region")
+	if err != nil {
+		return target{}, microerror.Mask(err)
+	}
+	kind, _, err := unstructured.NestedString(obj.Object, "spec", "identityRef", "kind")
+	if err != nil {
+		return target{}, microerror.Mask(err)
+	}
+	name, _, err := unstructured.NestedString(obj.Object, "spec", "identityRef", "name")
+	if err != nil {
+		return target{}, microerror.Mask(err)
+	}
+
+	switch kind {
+	case "", capaIdentityKindController:
+		return target{Region: region}, nil
+	case capaIdentityKindRole:
+		t, ok := identities[name]
+		if !ok {
+			return target{}, microerror.Maskf(notFoundError, "%s %#q", kind, name)
+		}
+		if t.RoleARN == "" {
+			return target{}, microerror.Maskf(notFoundError, "role ARN of %s %#q", kind, name)
+		}
+
+		t.Region = region
+		return t, nil
+	}
+
+	return target{}, microerror.Maskf(unsupportedIdentityError, "%s %#q", kind, name)
+}
@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCAPATarget(t *testing.T) {
+	identities := map[string]target{
+		"tenant": {RoleARN: "arn:aws:iam::222222222222:role/capa-controller", ExternalID: "external"},
+		"empty":  {},
+	}
+
+	testCases := []struct {
+		name string
+		spec map[string]interface{}
+
+		expectedTarget       target
+		expectedErrorMatcher func(error) bool
+	}{
+		{
+			name: "case 0: no identity uses the host account",
+			spec: map[string]interface{}{
+				"region": "eu-west-1",
+			},
+
+			expectedTarget: target{Region: "eu-west-1"},
+		},
+		{
+			name: "case 1: controller identity uses the host account",
+			spec: map[string]interface{}{
+				"region": "eu-west-1",
+				"identityRef": map[string]interface{}{
+					"kind": "AWSClusterControllerIdentity",
+					"name": "default",
+				},
+			},
+
+			expectedTarget: target{Region: "eu-west-1"},
+		},
+		{
+			name: "case 2: role identity",
+			spec: map[string]interface{}{
+				"region": "us-east-1",
+				"identityRef": map[string]interface{}{
+					"kind": "AWSClusterRoleIdentity",
+					"name": "tenant",
+				},
+			},
+
+			expectedTarget: target{RoleARN: "arn:aws:iam::222222222222:role/capa-controller", ExternalID: "external", Region: "us-east-1"},
+		},
+		{
+			name: "case 3: missing role identity",
+			spec: map[string]interface{}{
+				"region": "us-east-1",
+				"identityRef": map[string]interface{}{
+					"kind": "AWSClusterRoleIdentity",
+					"name": "missing",
+				},
+			},
+
+			expectedErrorMatcher: IsNotFound,
+		},
+		{
+			name: "case 4: role identity without role ARN",
+			spec: map[string]interface{}{
+				"identityRef": map[string]interface{}{
+					"kind": "AWSClusterRoleIdentity",
+					"name": "empty",
+				},
+			},
+
+			expectedErrorMatcher: IsNotFound,
+		},
+		{
+			name: "case 5: static identity",
+			spec: map[string]interface{}{
+				"identityRef": map[string]interface{}{
+					"kind": "AWSClusterStaticIdentity",
+					"name": "static",
+				},
+			},
+
+			expectedErrorMatcher: IsUnsupportedIdentity,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			obj := unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"spec": tc.spec,
+				},
+			}
+
+			result, err := capaTarget(obj, identities)
+
+			switch {
+			case err == nil && tc.expectedErrorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.expectedErrorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.expectedErrorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.expectedErrorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if result != tc.expectedTarget {
+				t.Fatalf("expected %#v, got %#v", tc.expectedTarget, result)
+			}
+		})
+	}
+}
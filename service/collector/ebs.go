@@ -0,0 +1,311 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/aws-collector/service/controller/key"
+	"github.com/giantswarm/aws-collector/service/internal/cache"
+)
+
+const (
+	labelVolumeID   = "volume_id"
+	labelVolumeType = "volume_type"
+)
+
+const (
+	// subsystemEBS will become the second part of the metric name, right after
+	// namespace.
+	subsystemEBS = "ebs"
+)
+
+const (
+	// tagKubernetesClusterPrefix is the prefix of the tag the AWS EBS CSI
+	// driver and the in-tree volume plugin put on volumes of persistent
+	// volumes, followed by the cluster ID.
+	tagKubernetesClusterPrefix = "kubernetes.io/cluster/"
+
+	volumeStateAvailable = "available"
+
+	// availableVolumeExpiration is the time after which volumes which were not
+	// seen anymore are forgotten. It must be longer than the collection
+	// interval, as the time a volume was first seen available is lost with it.
+	availableVolumeExpiration = 24 * time.Hour
+)
+
+var (
+	ebsVolumeCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemEBS, "volume_count"),
+		"Gauge about the number of EBS volumes by state and volume type.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+			labelState,
+			labelVolumeType,
+		},
+		nil,
+	)
+	ebsVolumeSizeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemEBS, "volume_size_gibibytes"),
+		"Gauge about the total provisioned size of EBS volumes by volume type.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+			labelVolumeType,
+		},
+		nil,
+	)
+	ebsVolumeAvailableAgeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemEBS, "volume_available_age_seconds"),
+		"Gauge about the time since EBS volumes which are not attached to any instance were first seen unattached by the collector.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+			labelVolumeID,
+			labelVolumeType,
+		},
+		nil,
+	)
+)
+
+type EBSConfig struct {
+	Helper *helper
+	Logger micrologger.Logger
+
+	InstallationName string
+}
+
+type EBS struct {
+	helper *helper
+	logger micrologger.Logger
+	// availableSince holds the time volumes were first seen available, keyed
+	// by volume ID. The creation time of a volume does not tell for how long
+	// it has been detached.
+	availableSince *cache.Cache[time.Time]
+
+	installationName string
+}
+
+type ebsVolumeKey struct {
+	Cluster      string
+	Organization string
+	State        string
+	VolumeType   string
+}
+
+type ebsVolumeSummary struct {
+	Count int
+	Size  int64
+}
+
+func NewEBS(config EBSConfig) (*EBS, error) {
+	if config.Helper == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Helper must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.InstallationName == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.InstallationName must not be empty", config)
+	}
+
+	e := &EBS{
+		helper:         config.Helper,
+		logger:         config.Logger,
+		availableSince: cache.NewCache[time.Time](availableVolumeExpiration),
+
+		installationName: config.InstallationName,
+	}
+
+	return e, nil
+}
+
+func (e *EBS) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	err := e.helper.ForEachAccount(ctx, collectorEBS, func(acc account) error {
+		err := e.collectForAccount(ctx, ch, acc)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (e *EBS) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- ebsVolumeCountDesc
+	ch <- ebsVolumeSizeDesc
+	ch <- ebsVolumeAvailableAgeDesc
+	return nil
+}
+
+func (e *EBS) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+	var volumes []*ec2.Volume
+	{
+		i := &ec2.DescribeVolumesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("tag:" + key.TagInstallation),
+					Values: aws.StringSlice([]string{e.installationName}),
+				},
+			},
+		}
+
+		err := acc.Clients.EC2.DescribeVolumesPagesWithContext(ctx, i, func(o *ec2.DescribeVolumesOutput, lastPage bool) bool {
+			volumes = append(volumes, o.Volumes...)
+			return true
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	summaries := summarizeVolumes(volumes)
+
+	sizes := map[ebsVolumeKey]int64{}
+	for k, s := range summaries {
+		ch <- prometheus.MustNewConstMetric(
+			ebsVolumeCountDesc,
+			prometheus.GaugeValue,
+			float64(s.Count),
+			acc.ID,
+			acc.Region,
+			k.Cluster,
+			e.installationName,
+			k.Organization,
+			k.State,
+			k.VolumeType,
+		)
+
+		k.State = ""
+		sizes[k] += s.Size
+	}
+
+	for k, size := range sizes {
+		ch <- prometheus.MustNewConstMetric(
+			ebsVolumeSizeDesc,
+			prometheus.GaugeValue,
+			float64(size),
+			acc.ID,
+			acc.Region,
+			k.Cluster,
+			e.installationName,
+			k.Organization,
+			k.VolumeType,
+		)
+	}
+
+	now := time.Now()
+	for _, v := range volumes {
+		id := aws.StringValue(v.VolumeId)
+		if aws.StringValue(v.State) != volumeStateAvailable {
+			e.availableSince.Delete(id)
+			continue
+		}
+
+		since := volumeAvailableSince(e.availableSince, id, now)
+
+		cluster, organization := resourceOwner(v.Tags)
+
+		ch <- prometheus.MustNewConstMetric(
+			ebsVolumeAvailableAgeDesc,
+			prometheus.GaugeValue,
+			now.Sub(since).Seconds(),
+			acc.ID,
+			acc.Region,
+			cluster,
+			e.installationName,
+			organization,
+			id,
+			aws.StringValue(v.VolumeType),
+		)
+	}
+
+	return nil
+}
+
+// volumeAvailableSince returns the time the given available volume was first
+// seen available, which is now if it was not seen before. The time is stored
+// again in order to not expire while the volume stays available.
+func volumeAvailableSince(c *cache.Cache[time.Time], volumeID string, now time.Time) time.Time {
+	since, ok := c.Get(volumeID)
+	if !ok {
+		since = now
+	}
+
+	c.Set(volumeID, since)
+
+	return since
+}
+
+// summarizeVolumes counts the given volumes and sums up their size in GiB by
+// cluster, organization, state and volume type.
+func summarizeVolumes(volumes []*ec2.Volume) map[ebsVolumeKey]ebsVolumeSummary {
+	summaries := map[ebsVolumeKey]ebsVolumeSummary{}
+
+	for _, v := range volumes {
+		cluster, organization := resourceOwner(v.Tags)
+
+		k := ebsVolumeKey{
+			Cluster:      cluster,
+			Organization: organization,
+			State:        aws.StringValue(v.State),
+			VolumeType:   aws.StringValue(v.VolumeType),
+		}
+
+		s := summaries[k]
+		s.Count++
+		s.Size += aws.Int64Value(v.Size)
+		summaries[k] = s
+	}
+
+	return summaries
+}
+
+// resourceOwner returns the cluster and organization of an EC2 resource based
+// on its tags. Resources created by Kubernetes, like volumes of persistent
+// volumes, might not have the Giant Swarm cluster tag, which is why the
+// Kubernetes cluster tag is used as fallback.
+func resourceOwner(tags []*ec2.Tag) (string, string) {
+	var cluster, kubernetesCluster, organization string
+
+	for _, tag := range tags {
+		k := aws.StringValue(tag.Key)
+
+		switch {
+		case k == tagCluster:
+			cluster = aws.StringValue(tag.Value)
+		case k == tagOrganization:
+			organization = aws.StringValue(tag.Value)
+		case strings.HasPrefix(k, tagKubernetesClusterPrefix):
+			kubernetesCluster = strings.TrimPrefix(k, tagKubernetesClusterPrefix)
+		}
+	}
+
+	if cluster == "" {
+		cluster = kubernetesCluster
+	}
+
+	return cluster, organization
+}
@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"github.com/giantswarm/aws-collector/service/internal/cache"
+)
+
+func TestSummarizeVolumes(t *testing.T) {
+	testCases := []struct {
+		name    string
+		volumes []*ec2.Volume
+
+		expectedSummaries map[ebsVolumeKey]ebsVolumeSummary
+	}{
+		{
+			name: "case 0: no volumes",
+
+			expectedSummaries: map[ebsVolumeKey]ebsVolumeSummary{},
+		},
+		{
+			name: "case 1: volumes are grouped by cluster, state and type",
+			volumes: []*ec2.Volume{
+				{
+					Size:  aws.Int64(100),
+					State: aws.String("in-use"),
+					Tags: []*ec2.Tag{
+						{Key: aws.String(tagCluster), Value: aws.String("a1b2c")},
+						{Key: aws.String(tagOrganization), Value: aws.String("acme")},
+					},
+					VolumeType: aws.String("gp3"),
+				},
+				{
+					Size:  aws.Int64(50),
+					State: aws.String("in-use"),
+					Tags: []*ec2.Tag{
+						{Key: aws.String(tagCluster), Value: aws.String("a1b2c")},
+						{Key: aws.String(tagOrganization), Value: aws.String("acme")},
+					},
+					VolumeType: aws.String("gp3"),
+				},
+				{
+					Size:  aws.Int64(10),
+					State: aws.String("available"),
+					Tags: []*ec2.Tag{
+						{Key: aws.String(tagCluster), Value: aws.String("a1b2c")},
+						{Key: aws.String(tagOrganization), Value: aws.String("acme")},
+					},
+					VolumeType: aws.String("gp3"),
+				},
+				{
+					Size:  aws.Int64(20),
+					State: aws.String("error"),
+					Tags: []*ec2.Tag{
+						{Key: aws.String(tagCluster), Value: aws.String("a1b2c")},
+						{Key: aws.String(tagOrganization), Value: aws.String("acme")},
+					},
+					VolumeType: aws.String("io2"),
+				},
+			},
+
+			expectedSummaries: map[ebsVolumeKey]ebsVolumeSummary{
+				{Cluster: "a1b2c", Organization: "acme", State: "in-use", VolumeType: "gp3"}:    {Count: 2, Size: 150},
+				{Cluster: "a1b2c", Organization: "acme", State: "available", VolumeType: "gp3"}: {Count: 1, Size: 10},
+				{Cluster: "a1b2c", Organization: "acme", State: "error", VolumeType: "io2"}:     {Count: 1, Size: 20},
+			},
+		},
+		{
+			name: "case 2: persistent volumes fall back to the Kubernetes cluster tag",
+			volumes: []*ec2.Volume{
+				{
+					Size:  aws.Int64(8),
+					State: aws.String("available"),
+					Tags: []*ec2.Tag{
+						{Key: aws.String("kubernetes.io/cluster/x9y8z"), Value: aws.String("owned")},
+					},
+					VolumeType: aws.String("gp2"),
+				},
+				{
+					Size:  aws.Int64(8),
+					State: aws.String("available"),
+					Tags: []*ec2.Tag{
+						{Key: aws.String("kubernetes.io/cluster/x9y8z"), Value: aws.String("owned")},
+						{Key: aws.String(tagCluster), Value: aws.String("a1b2c")},
+					},
+					VolumeType: aws.String("gp2"),
+				},
+			},
+
+			expectedSummaries: map[ebsVolumeKey]ebsVolumeSummary{
+				{Cluster: "x9y8z", State: "available", VolumeType: "gp2"}: {Count: 1, Size: 8},
+				{Cluster: "a1b2c", State: "available", VolumeType: "gp2"}: {Count: 1, Size: 8},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			summaries := summarizeVolumes(tc.volumes)
+
+			if !reflect.DeepEqual(summaries, tc.expectedSummaries) {
+				t.Fatalf("expected %#v, got %#v", tc.expectedSummaries, summaries)
+			}
+		})
+	}
+}
+
+func TestVolumeAvailableSince(t *testing.T) {
+	c := cache.NewCache[time.Time](time.Hour)
+	detached := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	since := volumeAvailableSince(c, "vol-1", detached)
+	if !since.Equal(detached) {
+		t.Fatalf("expected %s, got %s", detached, since)
+	}
+
+	since = volumeAvailableSince(c, "vol-1", detached.Add(time.Hour))
+	if !since.Equal(detached) {
+		t.Fatalf("expected %s, got %s", detached, since)
+	}
+
+	// The volume was attached and detached again in between.
+	c.Delete("vol-1")
+
+	since = volumeAvailableSince(c, "vol-1", detached.Add(2*time.Hour))
+	if !since.Equal(detached.Add(2 * time.Hour)) {
+		t.Fatalf("expected %s, got %s", detached.Add(2*time.Hour), since)
+	}
+}
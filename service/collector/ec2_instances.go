@@ -4,16 +4,17 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
-	"golang.org/x/sync/errgroup"
 
 	clientaws "github.com/giantswarm/aws-collector/client/aws"
 	"github.com/giantswarm/aws-collector/service/controller/key"
+	"github.com/giantswarm/aws-collector/service/internal/cache"
 )
 
 const (
@@ -38,8 +39,30 @@ const (
 	// labelPrivateDNS will contain the private dns name
 	labelPrivateDNS = "private_dns"
 
+	// labelEventCode will contain the code of a scheduled event, e.g.
+	// instance-retirement
+	labelEventCode = "event_code"
+
+	// labelEventID will contain the ID of a scheduled event
+	labelEventID = "event_id"
+
+	// labelImageID will contain the ID of the AMI the instance was launched
+	// from
+	labelImageID = "image_id"
+
+	// labelOSVersion will contain the OS version of the AMI
+	labelOSVersion = "os_version"
+
+	// labelExpectedOSVersion will contain the OS version of the release of
+	// the cluster
+	labelExpectedOSVersion = "expected_os_version"
+
 	// subsystemEC2 will become the second part of the metric name, right after namespace.
 	subsystemEC2 = "ec2"
+
+	// maxImageIDsInOneDescribeImagesBatch is the maximum number of values of
+	// a single filter of a DescribeImages request.
+	maxImageIDsInOneDescribeImagesBatch = 200
 )
 
 var (
@@ -49,6 +72,7 @@ var (
 		[]string{
 			labelInstance,
 			labelAccount,
+			labelRegion,
 			labelCluster,
 			labelInstallation,
 			labelOrganization,
@@ -61,6 +85,54 @@ var (
 		},
 		nil,
 	)
+	ec2InstanceScheduledEvent = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemEC2, "instance_scheduled_event_not_before_seconds"),
+		"Gauge about the earliest start of a scheduled event of an EC2 instance as Unix timestamp in seconds.",
+		[]string{
+			labelInstance,
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+			labelNodepool,
+			labelEventCode,
+			labelEventID,
+		},
+		nil,
+	)
+	ec2InstanceLaunchTime = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemEC2, "instance_launch_time_seconds"),
+		"Gauge about the launch time of an EC2 instance as Unix timestamp in seconds.",
+		[]string{
+			labelInstance,
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+			labelNodepool,
+			labelImageID,
+		},
+		nil,
+	)
+	ec2InstanceAMIOutdated = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemEC2, "instance_ami_outdated"),
+		"Gauge indicating whether the OS version of the AMI of an EC2 instance differs from the one of the release of its cluster. 1 = outdated, 0 = up to date",
+		[]string{
+			labelInstance,
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+			labelNodepool,
+			labelImageID,
+			labelOSVersion,
+			labelExpectedOSVersion,
+		},
+		nil,
+	)
 )
 
 // EC2InstancesConfig is this collector's configuration struct.
@@ -73,8 +145,9 @@ type EC2InstancesConfig struct {
 
 // EC2Instances is the main struct for this collector.
 type EC2Instances struct {
-	helper *helper
-	logger micrologger.Logger
+	helper     *helper
+	imageCache *cache.Cache[string]
+	logger     micrologger.Logger
 
 	installationName string
 }
@@ -94,7 +167,9 @@ func NewEC2Instances(config EC2InstancesConfig) (*EC2Instances, error) {
 
 	e := &EC2Instances{
 		helper: config.Helper,
-		logger: config.Logger,
+		// AMIs are immutable, so their OS version never changes.
+		imageCache: cache.NewCache[string](24 * time.Hour),
+		logger:     config.Logger,
 
 		installationName: config.InstallationName,
 	}
@@ -103,33 +178,22 @@ func NewEC2Instances(config EC2InstancesConfig) (*EC2Instances, error) {
 }
 
 // Collect is the main metrics collection function.
-func (e *EC2Instances) Collect(ch chan<- prometheus.Metric) error {
-	reconciledClusters, err := e.helper.ListReconciledClusters()
+func (e *EC2Instances) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	// The OS versions are only needed for the AMI drift metric, so failing to
+	// get them must not prevent the other metrics from being collected.
+	osVersions, err := e.helper.ClusterOSVersions(ctx)
 	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	awsClientsList, err := e.helper.GetAWSClients(context.Background(), reconciledClusters)
-	if err != nil {
-		return microerror.Mask(err)
+		e.logger.Log("level", "warning", "message", "failed getting OS versions of clusters", "stack", fmt.Sprintf("%#v", err))
 	}
 
-	var g errgroup.Group
-
-	for _, item := range awsClientsList {
-		awsClients := item
-
-		g.Go(func() error {
-			err := e.collectForAccount(ch, awsClients)
-			if err != nil {
-				return microerror.Mask(err)
-			}
-
-			return nil
-		})
-	}
+	err = e.helper.ForEachAccount(ctx, collectorEC2Instances, func(acc account) error {
+		err := e.collectForAccount(ctx, ch, acc, osVersions)
+		if err != nil {
+			return microerror.Mask(err)
+		}
 
-	err = g.Wait()
+		return nil
+	})
 	if err != nil {
 		return microerror.Mask(err)
 	}
@@ -140,20 +204,27 @@ func (e *EC2Instances) Collect(ch chan<- prometheus.Metric) error {
 // Describe emits the description for the metrics collected here.
 func (e *EC2Instances) Describe(ch chan<- *prometheus.Desc) error {
 	ch <- ec2InstanceStatus
+	ch <- ec2InstanceScheduledEvent
+	ch <- ec2InstanceLaunchTime
+	ch <- ec2InstanceAMIOutdated
+	ch <- ec2RunningVCPUsDesc
+	ch <- ec2VCPUQuotaDesc
 	return nil
 }
 
 // collectForAccount collects and emits our metric for one AWS account.
 //
 // We gather two separate collections first, then match them by instance ID:
-// - instance information, including tags, only for those tagged for our installation
+// - instance information, including tags
 // - instance status information
-func (e *EC2Instances) collectForAccount(ch chan<- prometheus.Metric, awsClients clientaws.Clients) error {
-	account, err := e.helper.AWSAccountID(awsClients)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
+//
+// The status metric is only emitted for instances tagged for our
+// installation, while the vCPUs are summed up for all instances of the
+// account, as all of them count against the same vCPU quotas.
+//
+// The given OS versions keyed by cluster ID are compared against the OS
+// version of the AMIs of the instances.
+func (e *EC2Instances) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account, osVersions map[string]string) error {
 	// Collect instance status info.
 	// map key will be the instance ID.
 	instanceStatuses := map[string]*ec2.InstanceStatus{}
@@ -163,55 +234,59 @@ func (e *EC2Instances) collectForAccount(ch chan<- prometheus.Metric, awsClients
 			MaxResults:          aws.Int64(1000),
 		}
 
-		for {
-			o, err := awsClients.EC2.DescribeInstanceStatus(input)
-			if err != nil {
-				return microerror.Mask(err)
-			}
-
-			// collect statuses
-			for _, s := range o.InstanceStatuses {
-				instanceStatuses[*s.InstanceId] = s
-			}
+		var statuses []*ec2.InstanceStatus
+		err := acc.Clients.EC2.DescribeInstanceStatusPagesWithContext(ctx, input, func(o *ec2.DescribeInstanceStatusOutput, lastPage bool) bool {
+			statuses = append(statuses, o.InstanceStatuses...)
+			return true
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
 
-			if o.NextToken == nil {
-				break
-			}
-			input.SetNextToken(*o.NextToken)
+		for _, s := range statuses {
+			instanceStatuses[*s.InstanceId] = s
 		}
 	}
 
 	// Collect instance info.
+	var allInstances []*ec2.Instance
 	instances := map[string]*ec2.Instance{}
 	{
 		input := &ec2.DescribeInstancesInput{
-			Filters: []*ec2.Filter{
-				{
-					Name: aws.String(fmt.Sprintf("tag:%s", key.TagInstallation)),
-					Values: []*string{
-						aws.String(e.installationName),
-					},
-				},
-			},
 			MaxResults: aws.Int64(1000),
 		}
 
-		for {
-			o, err := awsClients.EC2.DescribeInstances(input)
-			if err != nil {
-				return microerror.Mask(err)
+		err := acc.Clients.EC2.DescribeInstancesPagesWithContext(ctx, input, func(o *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, r := range o.Reservations {
+				allInstances = append(allInstances, r.Instances...)
 			}
+			return true
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
 
-			for _, reservation := range o.Reservations {
-				for _, instance := range reservation.Instances {
-					instances[*instance.InstanceId] = instance
-				}
+		for _, instance := range allInstances {
+			if hasTag(instance.Tags, key.TagInstallation, e.installationName) {
+				instances[*instance.InstanceId] = instance
 			}
+		}
+	}
 
-			if o.NextToken == nil {
-				break
-			}
-			input.SetNextToken(*o.NextToken)
+	var imageOSVersions map[string]string
+	{
+		var imageIDs []string
+		for _, instance := range instances {
+			imageIDs = append(imageIDs, aws.StringValue(instance.ImageId))
+		}
+
+		// The OS versions of the AMIs are only needed for the AMI drift
+		// metric, so failing to get them must not prevent the other metrics
+		// from being collected.
+		var err error
+		imageOSVersions, err = e.imageOSVersions(ctx, acc.Clients, imageIDs)
+		if err != nil {
+			e.logger.Log("level", "warning", "message", fmt.Sprintf("failed getting OS versions of AMIs in account %s and region %s", acc.ID, acc.Region), "stack", fmt.Sprintf("%#v", err))
 		}
 	}
 
@@ -223,13 +298,15 @@ func (e *EC2Instances) collectForAccount(ch chan<- prometheus.Metric, awsClients
 			continue
 		}
 
-		var az, cluster, instanceType, installation, lifecycle, organization, privateDNS, state, status string
+		var az, cluster, instanceType, installation, lifecycle, nodePool, organization, privateDNS, state, status string
 		for _, tag := range instances[instanceID].Tags {
 			switch *tag.Key {
 			case tagCluster:
 				cluster = *tag.Value
 			case key.TagInstallation:
 				installation = *tag.Value
+			case key.TagMachineDeployment, tagMachinePool:
+				nodePool = *tag.Value
 			case tagOrganization:
 				organization = *tag.Value
 			}
@@ -261,7 +338,8 @@ func (e *EC2Instances) collectForAccount(ch chan<- prometheus.Metric, awsClients
 			prometheus.GaugeValue,
 			float64(up),
 			instanceID,
-			account,
+			acc.ID,
+			acc.Region,
 			cluster,
 			installation,
 			organization,
@@ -272,7 +350,164 @@ func (e *EC2Instances) collectForAccount(ch chan<- prometheus.Metric, awsClients
 			status,
 			lifecycle,
 		)
+
+		for _, event := range scheduledEvents(statuses.Events) {
+			ch <- prometheus.MustNewConstMetric(
+				ec2InstanceScheduledEvent,
+				prometheus.GaugeValue,
+				float64(event.NotBefore.Unix()),
+				instanceID,
+				acc.ID,
+				acc.Region,
+				cluster,
+				installation,
+				organization,
+				nodePool,
+				aws.StringValue(event.Code),
+				aws.StringValue(event.InstanceEventId),
+			)
+		}
+
+		imageID := aws.StringValue(instances[instanceID].ImageId)
+
+		if instances[instanceID].LaunchTime != nil {
+			ch <- prometheus.MustNewConstMetric(
+				ec2InstanceLaunchTime,
+				prometheus.GaugeValue,
+				float64(instances[instanceID].LaunchTime.Unix()),
+				instanceID,
+				acc.ID,
+				acc.Region,
+				cluster,
+				installation,
+				organization,
+				nodePool,
+				imageID,
+			)
+		}
+
+		osVersion := imageOSVersions[imageID]
+		expectedOSVersion := osVersions[cluster]
+		if osVersion != "" && expectedOSVersion != "" {
+			outdated := 0
+			if osVersion != expectedOSVersion {
+				outdated = 1
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				ec2InstanceAMIOutdated,
+				prometheus.GaugeValue,
+				float64(outdated),
+				instanceID,
+				acc.ID,
+				acc.Region,
+				cluster,
+				installation,
+				organization,
+				nodePool,
+				imageID,
+				osVersion,
+				expectedOSVersion,
+			)
+		}
+	}
+
+	err := e.collectVCPUsForAccount(ctx, ch, acc, allInstances)
+	if err != nil {
+		return microerror.Mask(err)
 	}
 
 	return nil
 }
+
+// imageOSVersions returns the OS version of the given AMIs keyed by AMI ID.
+// AMIs are immutable, so their OS versions are cached and only unknown AMIs
+// are described. AMIs which are not Flatcar AMIs or which have been
+// deregistered have an empty OS version.
+func (e *EC2Instances) imageOSVersions(ctx context.Context, awsClients clientaws.Clients, imageIDs []string) (map[string]string, error) {
+	versions := map[string]string{}
+
+	var unknown []*string
+	for _, id := range imageIDs {
+		if _, ok := versions[id]; ok || id == "" {
+			continue
+		}
+
+		v, ok := e.imageCache.Get(id)
+		if ok {
+			versions[id] = v
+			continue
+		}
+
+		// Mark the AMI as seen, so that it is described only once.
+		versions[id] = ""
+		unknown = append(unknown, aws.String(id))
+	}
+
+	for len(unknown) > 0 {
+		batchSize := maxImageIDsInOneDescribeImagesBatch
+		if len(unknown) < batchSize {
+			batchSize = len(unknown)
+		}
+
+		batch := unknown[:batchSize]
+		unknown = unknown[batchSize:]
+
+		i := &ec2.DescribeImagesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("image-id"),
+					Values: batch,
+				},
+			},
+		}
+
+		// Filtering by image ID instead of passing the image IDs does not
+		// fail for deregistered AMIs.
+		o, err := awsClients.EC2.DescribeImagesWithContext(ctx, i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, image := range o.Images {
+			versions[aws.StringValue(image.ImageId)] = imageOSVersion(aws.StringValue(image.Name))
+		}
+		for _, id := range batch {
+			e.imageCache.Set(*id, versions[*id])
+		}
+	}
+
+	return versions, nil
+}
+
+// scheduledEvents returns the given events which are still upcoming or in
+// progress. AWS keeps completed and canceled events for a while, marking them
+// in their description.
+func scheduledEvents(events []*ec2.InstanceStatusEvent) []*ec2.InstanceStatusEvent {
+	var scheduled []*ec2.InstanceStatusEvent
+	for _, e := range events {
+		if e.NotBefore == nil {
+			continue
+		}
+
+		d := aws.StringValue(e.Description)
+		if strings.HasPrefix(d, "[Completed]") || strings.HasPrefix(d, "[Canceled]") {
+			continue
+		}
+
+		scheduled = append(scheduled, e)
+	}
+
+	return scheduled
+}
+
+// hasTag returns whether the given tags contain the given key and value.
+func hasTag(tags []*ec2.Tag, k string, v string) bool {
+	for _, t := range tags {
+		if aws.StringValue(t.Key) == k && aws.StringValue(t.Value) == v {
+			return true
+		}
+	}
+
+	return false
+}
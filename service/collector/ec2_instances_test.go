@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestScheduledEvents(t *testing.T) {
+	notBefore := time.Date(2024, 5, 6, 7, 0, 0, 0, time.UTC)
+
+	retirement := &ec2.InstanceStatusEvent{
+		Code:            aws.String(ec2.EventCodeInstanceRetirement),
+		Description:     aws.String("The instance is running on degraded hardware"),
+		InstanceEventId: aws.String("instance-event-1"),
+		NotBefore:       aws.Time(notBefore),
+	}
+	reboot := &ec2.InstanceStatusEvent{
+		Code:            aws.String(ec2.EventCodeSystemReboot),
+		Description:     aws.String("scheduled reboot"),
+		InstanceEventId: aws.String("instance-event-2"),
+		NotBefore:       aws.Time(notBefore),
+	}
+
+	testCases := []struct {
+		name   string
+		events []*ec2.InstanceStatusEvent
+
+		expectedEvents []*ec2.InstanceStatusEvent
+	}{
+		{
+			name: "case 0: no events",
+		},
+		{
+			name:   "case 1: upcoming events are returned",
+			events: []*ec2.InstanceStatusEvent{retirement, reboot},
+
+			expectedEvents: []*ec2.InstanceStatusEvent{retirement, reboot},
+		},
+		{
+			name: "case 2: completed and canceled events are ignored",
+			events: []*ec2.InstanceStatusEvent{
+				{
+					Code:        aws.String(ec2.EventCodeSystemMaintenance),
+					Description: aws.String("[Completed] scheduled maintenance"),
+					NotBefore:   aws.Time(notBefore),
+				},
+				{
+					Code:        aws.String(ec2.EventCodeInstanceStop),
+					Description: aws.String("[Canceled] The instance is running on degraded hardware"),
+					NotBefore:   aws.Time(notBefore),
+				},
+				retirement,
+			},
+
+			expectedEvents: []*ec2.InstanceStatusEvent{retirement},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			events := scheduledEvents(tc.events)
+
+			if !reflect.DeepEqual(events, tc.expectedEvents) {
+				t.Fatalf("expected %#v, got %#v", tc.expectedEvents, events)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+
+	clientaws "github.com/giantswarm/aws-collector/client/aws"
+)
+
+const (
+	// labelBucket is the vCPU quota bucket instances count against.
+	labelBucket = "bucket"
+)
+
+const (
+	vcpuBucketG        = "g"
+	vcpuBucketP        = "p"
+	vcpuBucketSpot     = "spot"
+	vcpuBucketStandard = "standard"
+	vcpuBucketX        = "x"
+
+	// maxInstanceTypesInOneDescribeInstanceTypesBatch is the maximum number
+	// of instance types a single DescribeInstanceTypes request accepts.
+	maxInstanceTypesInOneDescribeInstanceTypesBatch = 100
+)
+
+var (
+	ec2RunningVCPUsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemEC2, "running_vcpus"),
+		"Gauge about the number of vCPUs of pending and running instances by vCPU quota bucket.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelBucket,
+		},
+		nil,
+	)
+	ec2VCPUQuotaDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemEC2, "vcpu_quota"),
+		"Gauge about the applied vCPU service quota of a vCPU quota bucket.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelBucket,
+		},
+		nil,
+	)
+)
+
+var (
+	// vcpuQuotaCodes are the codes of the EC2 service quotas limiting the
+	// vCPUs of each bucket. Spot instances are only tracked for the standard
+	// instance families.
+	vcpuQuotaCodes = map[string]string{
+		// Running On-Demand G and VT instances.
+		vcpuBucketG: "L-DB2E81BA",
+		// Running On-Demand P instances.
+		vcpuBucketP: "L-417A185B",
+		// All Standard (A, C, D, H, I, M, R, T, Z) Spot Instance Requests.
+		vcpuBucketSpot: "L-34B43A08",
+		// Running On-Demand Standard (A, C, D, H, I, M, R, T, Z) instances.
+		vcpuBucketStandard: "L-1216C47A",
+		// Running On-Demand X instances.
+		vcpuBucketX: "L-7295265B",
+	}
+
+	// onDemandInstanceFamilyBuckets maps the families of on-demand instances
+	// not being standard instance families to their vCPU quota bucket.
+	onDemandInstanceFamilyBuckets = map[string]string{
+		"g":  vcpuBucketG,
+		"p":  vcpuBucketP,
+		"vt": vcpuBucketG,
+		"x":  vcpuBucketX,
+	}
+)
+
+func (e *EC2Instances) collectVCPUsForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account, instances []*ec2.Instance) error {
+	var types []string
+	for _, i := range instances {
+		if vcpuBucket(i) != "" {
+			types = append(types, aws.StringValue(i.InstanceType))
+		}
+	}
+
+	vcpus, err := e.helper.InstanceTypeVCPUs(ctx, acc.Clients, types)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	running := runningVCPUs(instances, vcpus)
+	e.helper.runningVCPUsCache.Set(acc.ID+"/"+acc.Region, running)
+
+	for bucket, count := range running {
+		ch <- prometheus.MustNewConstMetric(
+			ec2RunningVCPUsDesc,
+			prometheus.GaugeValue,
+			float64(count),
+			acc.ID,
+			acc.Region,
+			bucket,
+		)
+	}
+
+	quotas, err := e.helper.ServiceQuotas(ctx, acc, ec2ServiceCode)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for bucket, quotaCode := range vcpuQuotaCodes {
+		quota, ok := quotas[quotaCode]
+		if !ok {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			ec2VCPUQuotaDesc,
+			prometheus.GaugeValue,
+			quota.Applied,
+			acc.ID,
+			acc.Region,
+			bucket,
+		)
+	}
+
+	return nil
+}
+
+// RunningVCPUs returns the vCPUs of the pending and running instances of the
+// given account by vCPU quota bucket. The result of the last collection of the
+// ec2instances collector is used if available, so that all instances of the
+// account are not listed twice.
+func (h *helper) RunningVCPUs(ctx context.Context, acc account) (map[string]int64, error) {
+	cacheKey := acc.ID + "/" + acc.Region
+
+	running, ok := h.runningVCPUsCache.Get(cacheKey)
+	if ok {
+		return running, nil
+	}
+
+	i := &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("instance-state-name"),
+				Values: aws.StringSlice([]string{ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning}),
+			},
+		},
+	}
+
+	var instances []*ec2.Instance
+	err := acc.Clients.EC2.DescribeInstancesPagesWithContext(ctx, i, func(o *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, r := range o.Reservations {
+			instances = append(instances, r.Instances...)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var types []string
+	for _, i := range instances {
+		if vcpuBucket(i) != "" {
+			types = append(types, aws.StringValue(i.InstanceType))
+		}
+	}
+
+	vcpus, err := h.InstanceTypeVCPUs(ctx, acc.Clients, types)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	running = runningVCPUs(instances, vcpus)
+	h.runningVCPUsCache.Set(cacheKey, running)
+
+	return running, nil
+}
+
+// InstanceTypeVCPUs returns the default number of vCPUs of the given instance
+// types. The vCPUs of instance types do not change, so they are cached for all
+// collectors and only unknown instance types are described.
+func (h *helper) InstanceTypeVCPUs(ctx context.Context, awsClients clientaws.Clients, instanceTypes []string) (map[string]int64, error) {
+	vcpus := map[string]int64{}
+
+	var unknown []*string
+	for _, t := range instanceTypes {
+		if _, ok := vcpus[t]; ok {
+			continue
+		}
+
+		v, ok := h.vcpuCache.Get(t)
+		if ok {
+			vcpus[t] = v
+			continue
+		}
+
+		// Mark the instance type as seen, so that it is described only once.
+		vcpus[t] = 0
+		unknown = append(unknown, aws.String(t))
+	}
+
+	for len(unknown) > 0 {
+		batchSize := maxInstanceTypesInOneDescribeInstanceTypesBatch
+		if len(unknown) < batchSize {
+			batchSize = len(unknown)
+		}
+
+		i := &ec2.DescribeInstanceTypesInput{
+			InstanceTypes: unknown[:batchSize],
+		}
+		unknown = unknown[batchSize:]
+
+		var instanceTypes []*ec2.InstanceTypeInfo
+		err := awsClients.EC2.DescribeInstanceTypesPagesWithContext(ctx, i, func(o *ec2.DescribeInstanceTypesOutput, lastPage bool) bool {
+			instanceTypes = append(instanceTypes, o.InstanceTypes...)
+			return true
+		})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, t := range instanceTypes {
+			if t.VCpuInfo == nil {
+				continue
+			}
+
+			v := aws.Int64Value(t.VCpuInfo.DefaultVCpus)
+			vcpus[aws.StringValue(t.InstanceType)] = v
+			h.vcpuCache.Set(aws.StringValue(t.InstanceType), v)
+		}
+	}
+
+	return vcpus, nil
+}
+
+// runningVCPUs sums up the vCPUs of the given pending and running instances
+// by vCPU quota bucket, using the given vCPUs per instance type. All buckets
+// are part of the result.
+func runningVCPUs(instances []*ec2.Instance, vcpus map[string]int64) map[string]int64 {
+	counts := make(map[string]int64, len(vcpuQuotaCodes))
+	for bucket := range vcpuQuotaCodes {
+		counts[bucket] = 0
+	}
+
+	for _, i := range instances {
+		if i.State == nil {
+			continue
+		}
+		switch aws.StringValue(i.State.Name) {
+		case ec2.InstanceStateNamePending, ec2.InstanceStateNameRunning:
+		default:
+			continue
+		}
+
+		bucket := vcpuBucket(i)
+		if bucket == "" {
+			continue
+		}
+
+		counts[bucket] += vcpus[aws.StringValue(i.InstanceType)]
+	}
+
+	return counts
+}
+
+// vcpuBucket returns the vCPU quota bucket the given instance counts against,
+// or an empty string if it is not tracked.
+func vcpuBucket(instance *ec2.Instance) string {
+	family := instanceFamily(aws.StringValue(instance.InstanceType))
+
+	if aws.StringValue(instance.InstanceLifecycle) == ec2.InstanceLifecycleTypeSpot {
+		if standardInstanceFamilies[family] {
+			return vcpuBucketSpot
+		}
+		return ""
+	}
+
+	if standardInstanceFamilies[family] {
+		return vcpuBucketStandard
+	}
+
+	return onDemandInstanceFamilyBuckets[family]
+}
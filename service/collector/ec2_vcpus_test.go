@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestRunningVCPUs(t *testing.T) {
+	vcpus := map[string]int64{
+		"g5.xlarge":      4,
+		"m5.xlarge":      4,
+		"p4d.24xlarge":   96,
+		"r6i.large":      2,
+		"x2idn.16xlarge": 64,
+	}
+
+	testCases := []struct {
+		name      string
+		instances []*ec2.Instance
+
+		expectedVCPUs map[string]int64
+	}{
+		{
+			name: "case 0: no instances still reports all buckets",
+
+			expectedVCPUs: map[string]int64{
+				"g":        0,
+				"p":        0,
+				"spot":     0,
+				"standard": 0,
+				"x":        0,
+			},
+		},
+		{
+			name: "case 1: instances are summed up by bucket",
+			instances: []*ec2.Instance{
+				{InstanceType: aws.String("m5.xlarge"), State: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}},
+				{InstanceType: aws.String("r6i.large"), State: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNamePending)}},
+				{InstanceLifecycle: aws.String(ec2.InstanceLifecycleTypeSpot), InstanceType: aws.String("m5.xlarge"), State: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}},
+				{InstanceType: aws.String("g5.xlarge"), State: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}},
+				{InstanceType: aws.String("p4d.24xlarge"), State: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}},
+				{InstanceType: aws.String("x2idn.16xlarge"), State: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}},
+			},
+
+			expectedVCPUs: map[string]int64{
+				"g":        4,
+				"p":        96,
+				"spot":     4,
+				"standard": 6,
+				"x":        64,
+			},
+		},
+		{
+			name: "case 2: stopped instances and untracked families are ignored",
+			instances: []*ec2.Instance{
+				{InstanceType: aws.String("m5.xlarge"), State: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameStopped)}},
+				{InstanceLifecycle: aws.String(ec2.InstanceLifecycleTypeSpot), InstanceType: aws.String("g5.xlarge"), State: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}},
+				{InstanceType: aws.String("inf1.xlarge"), State: &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)}},
+			},
+
+			expectedVCPUs: map[string]int64{
+				"g":        0,
+				"p":        0,
+				"spot":     0,
+				"standard": 0,
+				"x":        0,
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			result := runningVCPUs(tc.instances, vcpus)
+
+			if !reflect.DeepEqual(result, tc.expectedVCPUs) {
+				t.Fatalf("expected %#v, got %#v", tc.expectedVCPUs, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/aws-collector/service/controller/key"
+)
+
+const (
+	// subsystemEIP will become the second part of the metric name, right after
+	// namespace.
+	subsystemEIP = "eip"
+)
+
+const (
+	// eipQuotaCode is the code of the "EC2-VPC Elastic IPs" quota of the EC2
+	// service.
+	eipQuotaCode   = "L-0263D0A3"
+	ec2ServiceCode = "ec2"
+)
+
+var (
+	eipAllocatedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemEIP, "allocated_count"),
+		"Gauge about the number of Elastic IPs allocated in an account and region.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+		},
+		nil,
+	)
+	eipAssociatedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemEIP, "associated_count"),
+		"Gauge about the number of Elastic IPs associated with an instance or network interface in an account and region.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+		},
+		nil,
+	)
+	eipUnassociatedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemEIP, "unassociated_count"),
+		"Gauge about the number of Elastic IPs which are allocated but not associated, and hence cost money without being used.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelOrganization,
+		},
+		nil,
+	)
+	eipQuotaDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemEIP, "quota"),
+		"Gauge about the Elastic IP quota of an account and region.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+		},
+		nil,
+	)
+)
+
+type EIPConfig struct {
+	Helper *helper
+	Logger micrologger.Logger
+}
+
+// EIP collects metrics about the Elastic IP usage of all accounts, regardless
+// of the installation the addresses belong to, since all of them count against
+// the same quota.
+type EIP struct {
+	helper *helper
+	logger micrologger.Logger
+}
+
+type eipOwner struct {
+	Cluster      string
+	Installation string
+	Organization string
+}
+
+type eipSummary struct {
+	Allocated    int
+	Associated   int
+	Unassociated map[eipOwner]int
+}
+
+func NewEIP(config EIPConfig) (*EIP, error) {
+	if config.Helper == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Helper must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	e := &EIP{
+		helper: config.Helper,
+		logger: config.Logger,
+	}
+
+	return e, nil
+}
+
+func (e *EIP) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	err := e.helper.ForEachAccount(ctx, collectorEIP, func(acc account) error {
+		err := e.collectForAccount(ctx, ch, acc)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (e *EIP) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- eipAllocatedDesc
+	ch <- eipAssociatedDesc
+	ch <- eipUnassociatedDesc
+	ch <- eipQuotaDesc
+	return nil
+}
+
+func (e *EIP) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+	o, err := acc.Clients.EC2.DescribeAddressesWithContext(ctx, &ec2.DescribeAddressesInput{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	s := summarizeAddresses(o.Addresses)
+
+	ch <- prometheus.MustNewConstMetric(
+		eipAllocatedDesc,
+		prometheus.GaugeValue,
+		float64(s.Allocated),
+		acc.ID,
+		acc.Region,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		eipAssociatedDesc,
+		prometheus.GaugeValue,
+		float64(s.Associated),
+		acc.ID,
+		acc.Region,
+	)
+
+	for owner, count := range s.Unassociated {
+		ch <- prometheus.MustNewConstMetric(
+			eipUnassociatedDesc,
+			prometheus.GaugeValue,
+			float64(count),
+			acc.ID,
+			acc.Region,
+			owner.Cluster,
+			owner.Installation,
+			owner.Organization,
+		)
+	}
+
+	quotas, err := e.helper.ServiceQuotas(ctx, acc, ec2ServiceCode)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	quota, ok := quotas[eipQuotaCode]
+	if !ok {
+		return nil
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		eipQuotaDesc,
+		prometheus.GaugeValue,
+		quota.Applied,
+		acc.ID,
+		acc.Region,
+	)
+
+	return nil
+}
+
+// summarizeAddresses counts the given Elastic IPs. Unassociated addresses are
+// counted by the cluster, installation and organization they are tagged with.
+func summarizeAddresses(addresses []*ec2.Address) eipSummary {
+	s := eipSummary{
+		Unassociated: map[eipOwner]int{},
+	}
+
+	for _, a := range addresses {
+		s.Allocated++
+
+		if a.AssociationId != nil || aws.StringValue(a.InstanceId) != "" {
+			s.Associated++
+			continue
+		}
+
+		cluster, organization := resourceOwner(a.Tags)
+
+		var installation string
+		for _, t := range a.Tags {
+			if aws.StringValue(t.Key) == key.TagInstallation {
+				installation = aws.StringValue(t.Value)
+			}
+		}
+
+		s.Unassociated[eipOwner{Cluster: cluster, Installation: installation, Organization: organization}]++
+	}
+
+	return s
+}
@@ -0,0 +1,87 @@
+package collector
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
+	"github.com/giantswarm/aws-collector/service/controller/key"
+)
+
+func TestSummarizeAddresses(t *testing.T) {
+	testCases := []struct {
+		name      string
+		addresses []*ec2.Address
+
+		expectedSummary eipSummary
+	}{
+		{
+			name: "case 0: no addresses",
+
+			expectedSummary: eipSummary{
+				Unassociated: map[eipOwner]int{},
+			},
+		},
+		{
+			name: "case 1: associated addresses are only counted per account",
+			addresses: []*ec2.Address{
+				{
+					AssociationId: aws.String("eipassoc-1"),
+					Tags: []*ec2.Tag{
+						{Key: aws.String(tagCluster), Value: aws.String("a1b2c")},
+					},
+				},
+				{InstanceId: aws.String("i-1")},
+			},
+
+			expectedSummary: eipSummary{
+				Allocated:    2,
+				Associated:   2,
+				Unassociated: map[eipOwner]int{},
+			},
+		},
+		{
+			name: "case 2: unassociated addresses are counted by owner",
+			addresses: []*ec2.Address{
+				{AssociationId: aws.String("eipassoc-1")},
+				{
+					Tags: []*ec2.Tag{
+						{Key: aws.String(tagCluster), Value: aws.String("a1b2c")},
+						{Key: aws.String(key.TagInstallation), Value: aws.String("gauss")},
+						{Key: aws.String(tagOrganization), Value: aws.String("acme")},
+					},
+				},
+				{
+					Tags: []*ec2.Tag{
+						{Key: aws.String(tagCluster), Value: aws.String("a1b2c")},
+						{Key: aws.String(key.TagInstallation), Value: aws.String("gauss")},
+						{Key: aws.String(tagOrganization), Value: aws.String("acme")},
+					},
+				},
+				{InstanceId: aws.String("")},
+			},
+
+			expectedSummary: eipSummary{
+				Allocated:  4,
+				Associated: 1,
+				Unassociated: map[eipOwner]int{
+					{Cluster: "a1b2c", Installation: "gauss", Organization: "acme"}: 2,
+					{}: 1,
+				},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			summary := summarizeAddresses(tc.addresses)
+
+			if !reflect.DeepEqual(summary, tc.expectedSummary) {
+				t.Fatalf("expected %#v, got %#v", tc.expectedSummary, summary)
+			}
+		})
+	}
+}
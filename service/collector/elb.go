@@ -2,9 +2,7 @@ package collector
 
 import (
 	"context"
-	"encoding/json"
 	"sync"
-	"time"
 
 	"github.com/aws/aws-sdk-go/service/elb"
 	"github.com/giantswarm/microerror"
@@ -14,15 +12,16 @@ import (
 
 	clientaws "github.com/giantswarm/aws-collector/client/aws"
 	"github.com/giantswarm/aws-collector/service/controller/key"
-	"github.com/giantswarm/aws-collector/service/internal/cache"
 )
 
 const (
-	// __ELBCache__ is used as temporal cache key to save ELB response.
-	prefixELBcacheKey = "__ELBCache__"
-	labelELB          = "elb"
+	labelELB = "elb"
 	// maxELBsInOneDescribeTagsBatch - https://docs.aws.amazon.com/elasticloadbalancing/2012-06-01/APIReference/API_DescribeTags.html
 	maxELBsInOneDescribeTagsBatch = 20
+	// maxConcurrentDescribeTagsBatches bounds the number of concurrent
+	// DescribeTags calls per account, which would otherwise grow with the
+	// number of ELBs.
+	maxConcurrentDescribeTagsBatches = 4
 )
 
 const (
@@ -40,6 +39,7 @@ var (
 		[]string{
 			labelELB,
 			labelAccount,
+			labelRegion,
 			labelCluster,
 			labelInstallation,
 			labelOrganization,
@@ -56,17 +56,12 @@ type ELBConfig struct {
 }
 
 type ELB struct {
-	cache  *elbCache
 	helper *helper
 	logger micrologger.Logger
 
 	installationName string
 }
 
-type elbCache struct {
-	cache *cache.StringCache
-}
-
 type elbInfoResponse struct {
 	Elbs []elbInfo
 }
@@ -90,7 +85,6 @@ func NewELB(config ELBConfig) (*ELB, error) {
 	}
 
 	e := &ELB{
-		cache:  newELBCache(time.Minute * 5),
 		helper: config.Helper,
 		logger: config.Logger,
 
@@ -100,69 +94,15 @@ func NewELB(config ELBConfig) (*ELB, error) {
 	return e, nil
 }
 
-func newELBCache(expiration time.Duration) *elbCache {
-	cache := &elbCache{
-		cache: cache.NewStringCache(expiration),
-	}
-
-	return cache
-}
-
-func (n *elbCache) Get(key string) (*elbInfoResponse, error) {
-	var c elbInfoResponse
-	raw, exists := n.cache.Get(getELBCacheKey(key))
-	if exists {
-		err := json.Unmarshal(raw, &c)
+func (e *ELB) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	err := e.helper.ForEachAccount(ctx, collectorELB, func(acc account) error {
+		err := e.collectForAccount(ctx, ch, acc)
 		if err != nil {
-			return nil, microerror.Mask(err)
+			return microerror.Mask(err)
 		}
-	}
-
-	return &c, nil
-}
 
-func (n *elbCache) Set(key string, content elbInfoResponse) error {
-	contentSerialized, err := json.Marshal(content)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	n.cache.Set(getELBCacheKey(key), contentSerialized)
-
-	return nil
-}
-
-func getELBCacheKey(key string) string {
-	return prefixELBcacheKey + key
-}
-
-func (e *ELB) Collect(ch chan<- prometheus.Metric) error {
-	reconciledClusters, err := e.helper.ListReconciledClusters()
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	awsClientsList, err := e.helper.GetAWSClients(context.Background(), reconciledClusters)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	var g errgroup.Group
-
-	for _, item := range awsClientsList {
-		awsClients := item
-
-		g.Go(func() error {
-			err := e.collectForAccount(context.Background(), ch, awsClients)
-			if err != nil {
-				return microerror.Mask(err)
-			}
-
-			return nil
-		})
-	}
-
-	err = g.Wait()
+		return nil
+	})
 	if err != nil {
 		return microerror.Mask(err)
 	}
@@ -175,34 +115,12 @@ func (e *ELB) Describe(ch chan<- *prometheus.Desc) error {
 	return nil
 }
 
-func (e *ELB) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, awsClients clientaws.Clients) error {
-	account, err := e.helper.AWSAccountID(awsClients)
+func (e *ELB) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+	elbInfo, err := getElbInfoFromAPI(ctx, acc.ID, e.installationName, acc.Clients)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	var elbInfo *elbInfoResponse
-	// Check if response is cached
-	elbInfo, err = e.cache.Get(account)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	// Cache empty, getting from API
-	if elbInfo == nil || elbInfo.Elbs == nil {
-		elbInfo, err = getElbInfoFromAPI(ctx, account, e.installationName, awsClients)
-		if err != nil {
-			return microerror.Mask(err)
-		}
-
-		if elbInfo != nil {
-			err = e.cache.Set(account, *elbInfo)
-			if err != nil {
-				return microerror.Mask(err)
-			}
-		}
-	}
-
 	if elbInfo != nil {
 		for _, lb := range elbInfo.Elbs {
 			ch <- prometheus.MustNewConstMetric(
@@ -210,7 +128,8 @@ func (e *ELB) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric
 				prometheus.GaugeValue,
 				lb.InstancesOutOfService,
 				lb.Name,
-				account,
+				acc.ID,
+				acc.Region,
 				lb.Tags[tagCluster],
 				lb.Tags[key.TagInstallation],
 				lb.Tags[tagOrganization],
@@ -228,11 +147,15 @@ func getElbInfoFromAPI(ctx context.Context, account string, installation string,
 	var loadBalancerNames []*string
 	{
 		i := &elb.DescribeLoadBalancersInput{}
-		o, err := awsClients.ELB.DescribeLoadBalancers(i)
+		var descriptions []*elb.LoadBalancerDescription
+		err := awsClients.ELB.DescribeLoadBalancersPagesWithContext(ctx, i, func(o *elb.DescribeLoadBalancersOutput, lastPage bool) bool {
+			descriptions = append(descriptions, o.LoadBalancerDescriptions...)
+			return true
+		})
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
-		for _, d := range o.LoadBalancerDescriptions {
+		for _, d := range descriptions {
 			loadBalancerNames = append(loadBalancerNames, d.LoadBalancerName)
 		}
 
@@ -250,7 +173,8 @@ func getElbInfoFromAPI(ctx context.Context, account string, installation string,
 		// single Describe request so it must be done in batches of
 		// maxELBsInOneBatch. In order to not spend so much time on this,
 		// perform requests concurrently and synchronize them with errgroup.
-		errGroup, _ := errgroup.WithContext(ctx)
+		errGroup, groupCtx := errgroup.WithContext(ctx)
+		errGroup.SetLimit(maxConcurrentDescribeTagsBatches)
 		// Slice for ELB tag description results.
 		var tagOutputs []*elb.DescribeTagsOutput
 
@@ -268,7 +192,7 @@ func getElbInfoFromAPI(ctx context.Context, account string, installation string,
 			lbNames = lbNames[batchSize:]
 
 			errGroup.Go(func() error {
-				o, err := awsClients.ELB.DescribeTags(tagInput)
+				o, err := awsClients.ELB.DescribeTagsWithContext(groupCtx, tagInput)
 				if err != nil {
 					return microerror.Mask(err)
 				}
@@ -317,7 +241,7 @@ func getElbInfoFromAPI(ctx context.Context, account string, installation string,
 				LoadBalancerName: &lbs[i].Name,
 			}
 
-			o, err := awsClients.ELB.DescribeInstanceHealth(describeInstanceHealthInput)
+			o, err := awsClients.ELB.DescribeInstanceHealthWithContext(ctx, describeInstanceHealthInput)
 			if err != nil {
 				return nil, microerror.Mask(err)
 			}
@@ -0,0 +1,464 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+
+	clientaws "github.com/giantswarm/aws-collector/client/aws"
+	"github.com/giantswarm/aws-collector/service/controller/key"
+)
+
+const (
+	labelLoadBalancer     = "load_balancer"
+	labelLoadBalancerType = "load_balancer_type"
+	labelTargetGroup      = "target_group"
+)
+
+const (
+	// subsystemELBv2 will become the second part of the metric name, right
+	// after namespace.
+	subsystemELBv2 = "elbv2"
+)
+
+var (
+	elbv2LoadBalancerStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemELBv2, "load_balancer_state"),
+		"Gauge about the state of application and network load balancers. Always 1, the state is given by the state label.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelLoadBalancer,
+			labelLoadBalancerType,
+			labelOrganization,
+			labelState,
+		},
+		nil,
+	)
+	elbv2ListenerCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemELBv2, "listener_count"),
+		"Gauge about the number of listeners of application and network load balancers.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelLoadBalancer,
+			labelLoadBalancerType,
+			labelOrganization,
+		},
+		nil,
+	)
+	elbv2TargetCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemELBv2, "target_count"),
+		"Gauge about the number of targets of a target group by health state.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelCluster,
+			labelInstallation,
+			labelLoadBalancer,
+			labelOrganization,
+			labelState,
+			labelTargetGroup,
+		},
+		nil,
+	)
+	elbv2LoadBalancerCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemELBv2, "load_balancer_count"),
+		"Gauge about the number of load balancers in an account and region by type, to be compared against the limits.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelLoadBalancerType,
+		},
+		nil,
+	)
+	elbv2TargetGroupCountDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemELBv2, "target_group_count"),
+		"Gauge about the number of target groups in an account and region, to be compared against the limits.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+		},
+		nil,
+	)
+	elbv2LimitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemELBv2, "limit"),
+		"Gauge about the ELBv2 limits of an account and region as reported by the ELBv2 API.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelName,
+		},
+		nil,
+	)
+)
+
+var (
+	// elbv2TargetStates are always emitted for every target group, so that
+	// alerts do not have to deal with absent series.
+	elbv2TargetStates = []string{
+		elbv2.TargetHealthStateEnumDraining,
+		elbv2.TargetHealthStateEnumHealthy,
+		elbv2.TargetHealthStateEnumUnhealthy,
+	}
+)
+
+type ELBv2Config struct {
+	Helper *helper
+	Logger micrologger.Logger
+
+	InstallationName string
+}
+
+// ELBv2 collects metrics about application and network load balancers. The
+// classic load balancers are covered by the ELB collector.
+type ELBv2 struct {
+	helper *helper
+	logger micrologger.Logger
+
+	installationName string
+}
+
+type elbv2LoadBalancer struct {
+	ARN          string
+	Cluster      string
+	Name         string
+	Organization string
+	State        string
+	Type         string
+}
+
+func NewELBv2(config ELBv2Config) (*ELBv2, error) {
+	if config.Helper == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Helper must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.InstallationName == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.InstallationName must not be empty", config)
+	}
+
+	e := &ELBv2{
+		helper: config.Helper,
+		logger: config.Logger,
+
+		installationName: config.InstallationName,
+	}
+
+	return e, nil
+}
+
+func (e *ELBv2) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	err := e.helper.ForEachAccount(ctx, collectorELBv2, func(acc account) error {
+		err := e.collectForAccount(ctx, ch, acc)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (e *ELBv2) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- elbv2LoadBalancerStateDesc
+	ch <- elbv2ListenerCountDesc
+	ch <- elbv2TargetCountDesc
+	ch <- elbv2LoadBalancerCountDesc
+	ch <- elbv2TargetGroupCountDesc
+	ch <- elbv2LimitDesc
+	return nil
+}
+
+func (e *ELBv2) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+	var loadBalancers []*elbv2.LoadBalancer
+	{
+		err := acc.Clients.ELBv2.DescribeLoadBalancersPagesWithContext(ctx, &elbv2.DescribeLoadBalancersInput{}, func(o *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+			loadBalancers = append(loadBalancers, o.LoadBalancers...)
+			return true
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	// All load balancers count against the limits, regardless of whether they
+	// belong to this installation.
+	{
+		counts := map[string]int{
+			elbv2.LoadBalancerTypeEnumApplication: 0,
+			elbv2.LoadBalancerTypeEnumNetwork:     0,
+		}
+		for _, lb := range loadBalancers {
+			counts[aws.StringValue(lb.Type)]++
+		}
+
+		for lbType, count := range counts {
+			ch <- prometheus.MustNewConstMetric(
+				elbv2LoadBalancerCountDesc,
+				prometheus.GaugeValue,
+				float64(count),
+				acc.ID,
+				acc.Region,
+				lbType,
+			)
+		}
+	}
+
+	lbs, err := e.ownLoadBalancers(ctx, acc.Clients, loadBalancers)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, lb := range lbs {
+		ch <- prometheus.MustNewConstMetric(
+			elbv2LoadBalancerStateDesc,
+			prometheus.GaugeValue,
+			GaugeValue,
+			acc.ID,
+			acc.Region,
+			lb.Cluster,
+			e.installationName,
+			lb.Name,
+			lb.Type,
+			lb.Organization,
+			lb.State,
+		)
+
+		var listeners int
+		{
+			i := &elbv2.DescribeListenersInput{
+				LoadBalancerArn: aws.String(lb.ARN),
+			}
+
+			var list []*elbv2.Listener
+			err := acc.Clients.ELBv2.DescribeListenersPagesWithContext(ctx, i, func(o *elbv2.DescribeListenersOutput, lastPage bool) bool {
+				list = append(list, o.Listeners...)
+				return true
+			})
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			listeners = len(list)
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			elbv2ListenerCountDesc,
+			prometheus.GaugeValue,
+			float64(listeners),
+			acc.ID,
+			acc.Region,
+			lb.Cluster,
+			e.installationName,
+			lb.Name,
+			lb.Type,
+			lb.Organization,
+		)
+	}
+
+	var targetGroups []*elbv2.TargetGroup
+	{
+		err = acc.Clients.ELBv2.DescribeTargetGroupsPagesWithContext(ctx, &elbv2.DescribeTargetGroupsInput{}, func(o *elbv2.DescribeTargetGroupsOutput, lastPage bool) bool {
+			targetGroups = append(targetGroups, o.TargetGroups...)
+			return true
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		elbv2TargetGroupCountDesc,
+		prometheus.GaugeValue,
+		float64(len(targetGroups)),
+		acc.ID,
+		acc.Region,
+	)
+
+	lbsByARN := make(map[string]elbv2LoadBalancer, len(lbs))
+	for _, lb := range lbs {
+		lbsByARN[lb.ARN] = lb
+	}
+
+	for _, tg := range targetGroups {
+		for _, arn := range tg.LoadBalancerArns {
+			lb, ok := lbsByARN[aws.StringValue(arn)]
+			if !ok {
+				continue
+			}
+
+			i := &elbv2.DescribeTargetHealthInput{
+				TargetGroupArn: tg.TargetGroupArn,
+			}
+			o, err := acc.Clients.ELBv2.DescribeTargetHealthWithContext(ctx, i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			for state, count := range countTargetStates(o.TargetHealthDescriptions) {
+				ch <- prometheus.MustNewConstMetric(
+					elbv2TargetCountDesc,
+					prometheus.GaugeValue,
+					float64(count),
+					acc.ID,
+					acc.Region,
+					lb.Cluster,
+					e.installationName,
+					lb.Name,
+					lb.Organization,
+					state,
+					aws.StringValue(tg.TargetGroupName),
+				)
+			}
+
+			// Target health does not depend on the load balancer, so a target
+			// group shared by several load balancers is reported once.
+			break
+		}
+	}
+
+	var limits []*elbv2.Limit
+	{
+		// The AWS SDK has no paginator for DescribeAccountLimits, so its
+		// pages are followed here.
+		i := &elbv2.DescribeAccountLimitsInput{}
+		for {
+			o, err := acc.Clients.ELBv2.DescribeAccountLimitsWithContext(ctx, i)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			limits = append(limits, o.Limits...)
+
+			if aws.StringValue(o.NextMarker) == "" {
+				break
+			}
+			i.Marker = o.NextMarker
+		}
+	}
+
+	for _, l := range limits {
+		max, err := strconv.ParseFloat(aws.StringValue(l.Max), 64)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			elbv2LimitDesc,
+			prometheus.GaugeValue,
+			max,
+			acc.ID,
+			acc.Region,
+			aws.StringValue(l.Name),
+		)
+	}
+
+	return nil
+}
+
+// ownLoadBalancers returns the given load balancers which are tagged with the
+// installation of this collector, together with their cluster and
+// organization.
+func (e *ELBv2) ownLoadBalancers(ctx context.Context, awsClients clientaws.Clients, loadBalancers []*elbv2.LoadBalancer) ([]elbv2LoadBalancer, error) {
+	byARN := make(map[string]*elbv2.LoadBalancer, len(loadBalancers))
+	var arns []*string
+	for _, lb := range loadBalancers {
+		byARN[aws.StringValue(lb.LoadBalancerArn)] = lb
+		arns = append(arns, lb.LoadBalancerArn)
+	}
+
+	var lbs []elbv2LoadBalancer
+
+	// AWS API has a limit for maximum number of resource ARNs in a single
+	// DescribeTags request, so it must be done in batches.
+	for len(arns) > 0 {
+		batchSize := maxELBsInOneDescribeTagsBatch
+		if len(arns) < batchSize {
+			batchSize = len(arns)
+		}
+
+		i := &elbv2.DescribeTagsInput{
+			ResourceArns: arns[:batchSize],
+		}
+		arns = arns[batchSize:]
+
+		o, err := awsClients.ELBv2.DescribeTagsWithContext(ctx, i)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, d := range o.TagDescriptions {
+			var cluster, installation, organization string
+			for _, t := range d.Tags {
+				switch aws.StringValue(t.Key) {
+				case tagCluster:
+					cluster = aws.StringValue(t.Value)
+				case key.TagInstallation:
+					installation = aws.StringValue(t.Value)
+				case tagOrganization:
+					organization = aws.StringValue(t.Value)
+				}
+			}
+
+			if installation != e.installationName {
+				continue
+			}
+
+			lb, ok := byARN[aws.StringValue(d.ResourceArn)]
+			if !ok {
+				continue
+			}
+
+			var state string
+			if lb.State != nil {
+				state = aws.StringValue(lb.State.Code)
+			}
+
+			lbs = append(lbs, elbv2LoadBalancer{
+				ARN:          aws.StringValue(lb.LoadBalancerArn),
+				Cluster:      cluster,
+				Name:         aws.StringValue(lb.LoadBalancerName),
+				Organization: organization,
+				State:        state,
+				Type:         aws.StringValue(lb.Type),
+			})
+		}
+	}
+
+	return lbs, nil
+}
+
+// countTargetStates counts the given targets by health state. The states in
+// elbv2TargetStates are always part of the result.
+func countTargetStates(descriptions []*elbv2.TargetHealthDescription) map[string]int {
+	counts := make(map[string]int, len(elbv2TargetStates))
+	for _, s := range elbv2TargetStates {
+		counts[s] = 0
+	}
+
+	for _, d := range descriptions {
+		if d.TargetHealth == nil {
+			continue
+		}
+
+		counts[aws.StringValue(d.TargetHealth.State)]++
+	}
+
+	return counts
+}
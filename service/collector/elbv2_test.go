@@ -0,0 +1,56 @@
+package collector
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+func TestCountTargetStates(t *testing.T) {
+	testCases := []struct {
+		name         string
+		descriptions []*elbv2.TargetHealthDescription
+
+		expectedCounts map[string]int
+	}{
+		{
+			name: "case 0: no targets still reports the default states",
+
+			expectedCounts: map[string]int{
+				"draining":  0,
+				"healthy":   0,
+				"unhealthy": 0,
+			},
+		},
+		{
+			name: "case 1: targets are counted by state",
+			descriptions: []*elbv2.TargetHealthDescription{
+				{TargetHealth: &elbv2.TargetHealth{State: aws.String("healthy")}},
+				{TargetHealth: &elbv2.TargetHealth{State: aws.String("healthy")}},
+				{TargetHealth: &elbv2.TargetHealth{State: aws.String("unhealthy")}},
+				{TargetHealth: &elbv2.TargetHealth{State: aws.String("initial")}},
+				{},
+			},
+
+			expectedCounts: map[string]int{
+				"draining":  0,
+				"healthy":   2,
+				"initial":   1,
+				"unhealthy": 1,
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			counts := countTargetStates(tc.descriptions)
+
+			if !reflect.DeepEqual(counts, tc.expectedCounts) {
+				t.Fatalf("expected %#v, got %#v", tc.expectedCounts, counts)
+			}
+		})
+	}
+}
@@ -71,6 +71,24 @@ func IsParsingFailed(err error) bool {
 	return microerror.Cause(err) == parsingFailedError
 }
 
+var timeoutError = &microerror.Error{
+	Kind: "timeoutError",
+}
+
+// IsTimeout asserts timeoutError.
+func IsTimeout(err error) bool {
+	return microerror.Cause(err) == timeoutError
+}
+
+var unsupportedIdentityError = &microerror.Error{
+	Kind: "unsupportedIdentityError",
+}
+
+// IsUnsupportedIdentity asserts unsupportedIdentityError.
+func IsUnsupportedIdentity(err error) bool {
+	return microerror.Cause(err) == unsupportedIdentityError
+}
+
 // IsUnsupportedPlan asserts that an error is due to Trusted Advisor not being
 // available with the current support plan.
 func IsUnsupportedPlan(err error) bool {
@@ -3,29 +3,29 @@ package collector
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
-	infrastructurev1alpha3 "github.com/giantswarm/apiextensions/v6/pkg/apis/infrastructure/v1alpha3"
 	"github.com/giantswarm/k8sclient/v7/pkg/k8sclient"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 
-	clientaws "github.com/giantswarm/aws-collector/client/aws"
-	"github.com/giantswarm/aws-collector/service/internal/accountid"
-	"github.com/giantswarm/aws-collector/service/internal/credential"
+	"github.com/giantswarm/aws-collector/service/internal/cache"
 )
 
 type helperConfig struct {
-	Clients k8sclient.Interface
-	Logger  micrologger.Logger
-
-	AWSConfig clientaws.Config
+	Clients  k8sclient.Interface
+	Logger   micrologger.Logger
+	Registry *accountRegistry
 }
 
 type helper struct {
-	clients k8sclient.Interface
-	logger  micrologger.Logger
-
-	awsConfig clientaws.Config
+	clients           k8sclient.Interface
+	logger            micrologger.Logger
+	quotaCache        *cache.Cache[map[string]serviceQuota]
+	registry          *accountRegistry
+	runningVCPUsCache *cache.Cache[map[string]int64]
+	vcpuCache         *cache.Cache[int64]
 }
 
 func newHelper(config helperConfig) (*helper, error) {
@@ -35,155 +35,93 @@ func newHelper(config helperConfig) (*helper, error) {
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
-
-	var emptyAWSConfig clientaws.Config
-	if config.AWSConfig == emptyAWSConfig {
-		return nil, microerror.Maskf(invalidConfigError, "%T.AWSConfig must not be empty", config)
+	if config.Registry == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Registry must not be empty", config)
 	}
 
 	h := &helper{
 		clients: config.Clients,
 		logger:  config.Logger,
-
-		awsConfig: config.AWSConfig,
+		// Quotas are changed by request to AWS support and they are
+		// considered quite static information, then 12 hours for the cache
+		// expiration is a reasonable value.
+		quotaCache: cache.NewCache[map[string]serviceQuota](12 * time.Hour),
+		registry:   config.Registry,
+		// Listing all instances of an account is expensive, so the running
+		// vCPUs found by the ec2instances collector are shared with the
+		// servicequota collector.
+		runningVCPUsCache: cache.NewCache[map[string]int64](10 * time.Minute),
+		// The vCPUs of instance types never change.
+		vcpuCache: cache.NewCache[int64](24 * time.Hour),
 	}
 
 	return h, nil
 }
 
-// GetARNs list all unique aws IAM ARN from credential secret.
-func (h *helper) GetARNs(ctx context.Context, clusterList *infrastructurev1alpha3.AWSClusterList) ([]string, error) {
-	var arns []string
-
-	// Get unique ARNs.
-	arnsMap := make(map[string]bool)
-	for _, clusterCR := range clusterList.Items {
-		arn, err := credential.GetARN(ctx, h.clients.K8sClient(), clusterCR)
-		// Collect as many ARNs as possible in order to provide most metrics.
-		// Ignore old cluster which do not have credential.
-		if credential.IsCredentialNameEmptyError(err) {
-			continue
-		} else if credential.IsCredentialNamespaceEmptyError(err) {
-			continue
-		} else if err != nil {
-			return nil, microerror.Mask(err)
-		}
-
-		arnsMap[arn] = true
-	}
-
-	// Ensure we check the default guest account for old cluster not having credential.
-	arn, err := credential.GetDefaultARN(ctx, h.clients.K8sClient())
+// GetAccounts returns the AWS accounts of every guest cluster plus the host
+// cluster account, once per region clusters of the account are located in, as
+// resolved by the shared account registry.
+func (h *helper) GetAccounts(ctx context.Context) ([]account, error) {
+	accounts, err := h.registry.Accounts(ctx)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
-	arnsMap[arn] = true
-
-	for arn := range arnsMap {
-		arns = append(arns, arn)
-	}
 
-	return arns, nil
+	return accounts, nil
 }
 
-// GetAWSClients return a list of aws clients for every guest cluster account plus
-// the host cluster account.
-func (h *helper) GetAWSClients(ctx context.Context, clusterList *infrastructurev1alpha3.AWSClusterList) ([]clientaws.Clients, error) {
-	var (
-		clients    []clientaws.Clients
-		clientsMap = make(map[string]clientaws.Clients)
-	)
-
-	arns, err := h.GetARNs(ctx, clusterList)
+// ForEachAccount calls collect concurrently for every resolved account and
+// region. Errors of single accounts are logged and tracked in the account
+// error metrics instead of being returned, so that one failing account does
+// not drop the metrics gathered for all other accounts.
+func (h *helper) ForEachAccount(ctx context.Context, collector string, collect func(account) error) error {
+	accounts, err := h.GetAccounts(ctx)
 	if err != nil {
-		return nil, microerror.Mask(err)
+		return microerror.Mask(err)
 	}
 
-	// addClientFunc add awsClients to clients using account id as key to guaranatee uniqueness.
-	addClientFunc := func(awsClients clientaws.Clients, clients map[string]clientaws.Clients) error {
-		accountID, err := h.AWSAccountID(awsClients)
-		if err != nil {
-			return microerror.Mask(err)
-		}
-
-		_, ok := clients[accountID]
-		if !ok {
-			clients[accountID] = awsClients
-		}
+	h.forEach(collector, accounts, collect)
 
-		return nil
-	}
+	return nil
+}
 
-	// Control plane account.
-	awsClients, err := clientaws.NewClients(h.awsConfig)
+// ForEachGlobalAccount works like ForEachAccount but calls collect only once
+// per account, regardless of the number of regions. It is meant for global
+// AWS services like Trusted Advisor.
+func (h *helper) ForEachGlobalAccount(ctx context.Context, collector string, collect func(account) error) error {
+	accounts, err := h.registry.GlobalAccounts(ctx)
 	if err != nil {
-		return nil, microerror.Mask(err)
-	}
-	err = addClientFunc(awsClients, clientsMap)
-	if err != nil {
-		return nil, microerror.Mask(err)
+		return microerror.Mask(err)
 	}
 
-	// Tenant cluster accounts.
-	for _, arn := range arns {
-		awsConfig := h.awsConfig
-		awsConfig.RoleARN = arn
-
-		awsClients, err := clientaws.NewClients(awsConfig)
-		if err != nil {
-			return nil, microerror.Mask(err)
-		}
-		err = addClientFunc(awsClients, clientsMap)
-		if err != nil {
-			return nil, microerror.Mask(err)
-		}
-	}
+	h.forEach(collector, accounts, collect)
 
-	// Convert map to slice.
-	for accountID, client := range clientsMap {
-		clients = append(clients, client)
-		h.logger.Log("level", "debug", "message", fmt.Sprintf("collecting metrics in account: %s", accountID))
-	}
-
-	return clients, nil
+	return nil
 }
 
-// AWSAccountID return the AWS account ID.
-func (h *helper) AWSAccountID(awsClients clientaws.Clients) (string, error) {
-	var err error
-
-	var accountIDService *accountid.AccountID
-	{
-		c := accountid.Config{
-			Logger: h.logger,
-			STS:    awsClients.STS,
-		}
-
-		accountIDService, err = accountid.New(c)
-		if err != nil {
-			return "", microerror.Mask(err)
-		}
-	}
+func (h *helper) forEach(collector string, accounts []account, collect func(account) error) {
+	var wg sync.WaitGroup
 
-	accountID, err := accountIDService.Lookup()
-	if err != nil {
-		return "", microerror.Mask(err)
-	}
+	for _, item := range accounts {
+		acc := item
 
-	return accountID, nil
-}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
 
-// ListReconciledClusters provides a list of clusters
-func (h *helper) ListReconciledClusters() (*infrastructurev1alpha3.AWSClusterList, error) {
-	ctx := context.Background()
+			start := time.Now()
+			err := collect(acc)
+			collectorAccountDuration.WithLabelValues(collector, acc.ID, acc.Region).Observe(time.Since(start).Seconds())
+			if err != nil {
+				accountErrors.WithLabelValues(collector, acc.ID, acc.Region, accountErrorReason(err)).Inc()
+				accountUp.WithLabelValues(collector, acc.ID, acc.Region).Set(0)
+				h.logger.Log("level", "warning", "message", fmt.Sprintf("failed collecting %s metrics for account %s in region %s", collector, acc.ID, acc.Region), "stack", fmt.Sprintf("%#v", err))
+				return
+			}
 
-	clusters := &infrastructurev1alpha3.AWSClusterList{}
-	err := h.clients.CtrlClient().List(
-		ctx,
-		clusters,
-	)
-	if err != nil {
-		return nil, microerror.Mask(err)
+			accountUp.WithLabelValues(collector, acc.ID, acc.Region).Set(1)
+		}()
 	}
-	return clusters, err
+
+	wg.Wait()
 }
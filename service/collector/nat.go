@@ -2,9 +2,7 @@ package collector
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -14,14 +12,11 @@ import (
 
 	clientaws "github.com/giantswarm/aws-collector/client/aws"
 	"github.com/giantswarm/aws-collector/service/controller/key"
-	"github.com/giantswarm/aws-collector/service/internal/cache"
 )
 
 const (
-	// __NATCache__ is used as temporal cache key to save NAT response.
-	prefixNATcacheKey = "__NATCache__"
-	labelVPC          = "vpc"
-	labelAZ           = "availability_zone"
+	labelVPC = "vpc"
+	labelAZ  = "availability_zone"
 )
 
 const (
@@ -34,6 +29,7 @@ var (
 		"NAT limits information.",
 		[]string{
 			labelAccountID,
+			labelRegion,
 			labelVPC,
 			labelAZ,
 		},
@@ -49,17 +45,12 @@ type NATConfig struct {
 }
 
 type NAT struct {
-	cache  *natCache
 	helper *helper
 	logger micrologger.Logger
 
 	installationName string
 }
 
-type natCache struct {
-	cache *cache.StringCache
-}
-
 type natInfoResponse struct {
 	Vpcs map[string]vpcInfo
 }
@@ -81,11 +72,6 @@ func NewNAT(config NATConfig) (*NAT, error) {
 	}
 
 	v := &NAT{
-		// AWS operator creates at this moment one NAT for each private subnet (node
-		// pool). As clusters are not created nor changed so often, and the process
-		// can take around 20 minutes, 30 minutes for the cache expiration is a
-		// reasonable value.
-		cache:  newNATCache(time.Minute * 30),
 		helper: config.Helper,
 		logger: config.Logger,
 
@@ -95,62 +81,19 @@ func NewNAT(config NATConfig) (*NAT, error) {
 	return v, nil
 }
 
-func newNATCache(expiration time.Duration) *natCache {
-	cache := &natCache{
-		cache: cache.NewStringCache(expiration),
-	}
-
-	return cache
-}
-
-func (n *natCache) Get(accID string) (*natInfoResponse, error) {
-	var c natInfoResponse
-	raw, exists := n.cache.Get(getNATCacheKey(accID))
-	if exists {
-		err := json.Unmarshal(raw, &c)
+func (v *NAT) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	err := v.helper.ForEachAccount(ctx, collectorNAT, func(acc account) error {
+		err := v.collectForAccount(ctx, ch, acc)
 		if err != nil {
-			return nil, microerror.Mask(err)
+			return microerror.Mask(err)
 		}
-	}
-
-	return &c, nil
-}
 
-func (n *natCache) Set(accID string, content natInfoResponse) error {
-	contentSerialized, err := json.Marshal(content)
+		return nil
+	})
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	n.cache.Set(getNATCacheKey(accID), contentSerialized)
-
-	return nil
-}
-
-func getNATCacheKey(accID string) string {
-	return prefixNATcacheKey + accID
-}
-
-func (v *NAT) Collect(ch chan<- prometheus.Metric) error {
-	reconciledClusters, err := v.helper.ListReconciledClusters()
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	awsClientsList, err := v.helper.GetAWSClients(context.Background(), reconciledClusters)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	for _, item := range awsClientsList {
-		awsClients := item
-
-		err := v.collectForAccount(ch, awsClients)
-		if err != nil {
-			return microerror.Mask(err)
-		}
-	}
-
 	return nil
 }
 
@@ -159,32 +102,12 @@ func (v *NAT) Describe(ch chan<- *prometheus.Desc) error {
 	return nil
 }
 
-func (v *NAT) collectForAccount(ch chan<- prometheus.Metric, awsClients clientaws.Clients) error {
-	accountID, err := v.helper.AWSAccountID(awsClients)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	var natInfo *natInfoResponse
-	// Check if response is cached
-	natInfo, err = v.cache.Get(accountID)
+func (v *NAT) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+	natInfo, err := getNatInfoFromAPI(ctx, acc.Clients)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	//Cache empty, getting from API
-	if natInfo == nil || natInfo.Vpcs == nil {
-		natInfo, err = getNatInfoFromAPI(accountID, awsClients)
-		if err != nil {
-			return microerror.Mask(err)
-		}
-
-		err = v.cache.Set(accountID, *natInfo)
-		if err != nil {
-			return microerror.Mask(err)
-		}
-	}
-
 	if natInfo != nil {
 		for vpcID, vpcInfo := range natInfo.Vpcs {
 			for azName, azValue := range vpcInfo.NatGatewaysByZone {
@@ -192,7 +115,8 @@ func (v *NAT) collectForAccount(ch chan<- prometheus.Metric, awsClients clientaw
 					natDesc,
 					prometheus.GaugeValue,
 					azValue,
-					accountID,
+					acc.ID,
+					acc.Region,
 					vpcID,
 					azName,
 				)
@@ -205,7 +129,7 @@ func (v *NAT) collectForAccount(ch chan<- prometheus.Metric, awsClients clientaw
 
 // getNatInfoFromAPI collect from AWS API the number of NAT Gateways by Availability Zone for
 // each VPC of the installation
-func getNatInfoFromAPI(accountID string, awsClients clientaws.Clients) (*natInfoResponse, error) {
+func getNatInfoFromAPI(ctx context.Context, awsClients clientaws.Clients) (*natInfoResponse, error) {
 	var res natInfoResponse
 	res.Vpcs = make(map[string]vpcInfo)
 
@@ -220,13 +144,17 @@ func getNatInfoFromAPI(accountID string, awsClients clientaws.Clients) (*natInfo
 			},
 		},
 	}
-	rv, err := awsClients.EC2.DescribeVpcs(iv)
+	var vpcs []*ec2.Vpc
+	err := awsClients.EC2.DescribeVpcsPagesWithContext(ctx, iv, func(o *ec2.DescribeVpcsOutput, lastPage bool) bool {
+		vpcs = append(vpcs, o.Vpcs...)
+		return true
+	})
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
 	// 2. Get all NAT GWs for each VPC
-	for _, vpc := range rv.Vpcs {
+	for _, vpc := range vpcs {
 		vpcID := *vpc.VpcId
 		res.Vpcs[vpcID] = vpcInfo{
 			NatGatewaysByZone: make(map[string]float64),
@@ -242,32 +170,55 @@ func getNatInfoFromAPI(accountID string, awsClients clientaws.Clients) (*natInfo
 				},
 			},
 		}
-		rn, err := awsClients.EC2.DescribeNatGateways(in)
+		var natGateways []*ec2.NatGateway
+		err := awsClients.EC2.DescribeNatGatewaysPagesWithContext(ctx, in, func(o *ec2.DescribeNatGatewaysOutput, lastPage bool) bool {
+			natGateways = append(natGateways, o.NatGateways...)
+			return true
+		})
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
 
-		// 3. Get all the subnets for each NAT GW
-		for _, nat := range rn.NatGateways {
-			is := &ec2.DescribeSubnetsInput{
-				SubnetIds: []*string{
-					nat.SubnetId,
+		if len(natGateways) == 0 {
+			continue
+		}
+
+		// 3. Get the subnets of all NAT GWs of the VPC
+		var subnetIDs []*string
+		for _, nat := range natGateways {
+			subnetIDs = append(subnetIDs, nat.SubnetId)
+		}
+
+		is := &ec2.DescribeSubnetsInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("subnet-id"),
+					Values: subnetIDs,
 				},
-			}
-			rs, err := awsClients.EC2.DescribeSubnets(is)
-			if err != nil {
-				return nil, microerror.Mask(err)
-			}
+			},
+		}
+		var subnets []*ec2.Subnet
+		err = awsClients.EC2.DescribeSubnetsPagesWithContext(ctx, is, func(o *ec2.DescribeSubnetsOutput, lastPage bool) bool {
+			subnets = append(subnets, o.Subnets...)
+			return true
+		})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
 
-			// 4. Store the number of GWs by Availability Zone
-			for _, sub := range rs.Subnets {
-				zoneID := *sub.AvailabilityZoneId
-				if _, exists := res.Vpcs[vpcID].NatGatewaysByZone[zoneID]; exists {
-					res.Vpcs[vpcID].NatGatewaysByZone[zoneID] = res.Vpcs[vpcID].NatGatewaysByZone[zoneID] + 1
-				} else {
-					res.Vpcs[vpcID].NatGatewaysByZone[zoneID] = 1
-				}
+		zones := map[string]string{}
+		for _, sub := range subnets {
+			zones[*sub.SubnetId] = *sub.AvailabilityZoneId
+		}
+
+		// 4. Store the number of GWs by Availability Zone
+		for _, nat := range natGateways {
+			zoneID, ok := zones[aws.StringValue(nat.SubnetId)]
+			if !ok {
+				continue
 			}
+
+			res.Vpcs[vpcID].NatGatewaysByZone[zoneID]++
 		}
 
 	}
@@ -0,0 +1,382 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+
+	clientaws "github.com/giantswarm/aws-collector/client/aws"
+	"github.com/giantswarm/aws-collector/service/controller/key"
+)
+
+const (
+	labelNATGateway = "nat_gateway_id"
+)
+
+const (
+	natGatewayFailureReasonEIPLimitExceeded     = "eip_limit_exceeded"
+	natGatewayFailureReasonEIPUnavailable       = "eip_unavailable"
+	natGatewayFailureReasonGatewayLimitExceeded = "nat_gateway_limit_exceeded"
+	natGatewayFailureReasonInsufficientIPs      = "insufficient_ips"
+	natGatewayFailureReasonInternalError        = "internal_error"
+	natGatewayFailureReasonNoInternetGateway    = "no_internet_gateway"
+	natGatewayFailureReasonOther                = "other"
+	natGatewayFailureReasonSubnetNotFound       = "subnet_not_found"
+
+	// natGatewayMetricsPeriod is the CloudWatch period the traffic metrics
+	// of NAT gateways are aggregated over.
+	natGatewayMetricsPeriod = 5 * time.Minute
+	// natGatewayMetricsDelay is the time NAT gateway metrics take to be
+	// available in CloudWatch. The last period ending before now minus the
+	// delay is reported.
+	natGatewayMetricsDelay = 5 * time.Minute
+	// maxMetricDataQueries is the maximum number of queries of a single
+	// GetMetricData request.
+	maxMetricDataQueries = 500
+)
+
+var (
+	natGatewayLabels = []string{
+		labelAccountID,
+		labelRegion,
+		labelNATGateway,
+		labelVPC,
+		labelCluster,
+		labelInstallation,
+		labelOrganization,
+	}
+
+	natGatewayStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemNAT, "gateway_state"),
+		"Gauge about the state of NAT gateways. Always 1, the state is given by the state label.",
+		append(natGatewayLabels, labelState),
+		nil,
+	)
+	natGatewayFailureDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemNAT, "gateway_failure"),
+		"Gauge about the reason failed NAT gateways could not be created, classified based on their failure code. Always 1, the reason is given by the reason label.",
+		append(natGatewayLabels, labelReason),
+		nil,
+	)
+	natGatewayErrorPortAllocationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemNAT, "gateway_error_port_allocation_count"),
+		"Gauge about the number of times NAT gateways could not allocate a source port in the last CloudWatch period, i.e. port allocation exhaustion.",
+		natGatewayLabels,
+		nil,
+	)
+	natGatewayPacketsDropDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemNAT, "gateway_packets_drop_count"),
+		"Gauge about the number of packets dropped by NAT gateways in the last CloudWatch period.",
+		natGatewayLabels,
+		nil,
+	)
+	natGatewayActiveConnectionDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemNAT, "gateway_active_connection_count"),
+		"Gauge about the maximum number of concurrent active TCP connections through NAT gateways in the last CloudWatch period.",
+		natGatewayLabels,
+		nil,
+	)
+)
+
+var (
+	// natGatewayFailureReasons classify the failure codes of failed NAT
+	// gateways.
+	natGatewayFailureReasons = map[string]string{
+		"AddressLimitExceeded":              natGatewayFailureReasonEIPLimitExceeded,
+		"Gateway.NotAttached":               natGatewayFailureReasonNoInternetGateway,
+		"InsufficientFreeAddressesInSubnet": natGatewayFailureReasonInsufficientIPs,
+		"InternalError":                     natGatewayFailureReasonInternalError,
+		"InvalidAllocationID.NotFound":      natGatewayFailureReasonEIPUnavailable,
+		"InvalidSubnetID.NotFound":          natGatewayFailureReasonSubnetNotFound,
+		"NatGatewayLimitExceeded":           natGatewayFailureReasonGatewayLimitExceeded,
+		"Resource.AlreadyAssociated":        natGatewayFailureReasonEIPUnavailable,
+	}
+
+	// natGatewayMetrics are the CloudWatch metrics reported for available NAT
+	// gateways together with the statistic aggregating them over the period.
+	natGatewayMetrics = []struct {
+		Desc      *prometheus.Desc
+		Name      string
+		Statistic string
+	}{
+		{
+			Desc:      natGatewayErrorPortAllocationDesc,
+			Name:      "ErrorPortAllocation",
+			Statistic: cloudwatch.StatisticSum,
+		},
+		{
+			Desc:      natGatewayPacketsDropDesc,
+			Name:      "PacketsDropCount",
+			Statistic: cloudwatch.StatisticSum,
+		},
+		{
+			Desc:      natGatewayActiveConnectionDesc,
+			Name:      "ActiveConnectionCount",
+			Statistic: cloudwatch.StatisticMaximum,
+		},
+	}
+)
+
+type NATGatewayConfig struct {
+	Helper *helper
+	Logger micrologger.Logger
+
+	// CloudWatchMetrics enables collecting the traffic metrics of NAT
+	// gateways from CloudWatch, which is charged per requested metric.
+	CloudWatchMetrics bool
+	InstallationName  string
+}
+
+// NATGateway reports the state of every NAT gateway of the installation. It
+// is separate from the nat collector, which only refreshes slowly, so that
+// failed gateways and the CloudWatch metrics of every period are reported in
+// time.
+type NATGateway struct {
+	helper *helper
+	logger micrologger.Logger
+
+	cloudWatchMetrics bool
+	installationName  string
+}
+
+func NewNATGateway(config NATGatewayConfig) (*NATGateway, error) {
+	if config.Helper == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Helper must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.InstallationName == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.InstallationName must not be empty", config)
+	}
+
+	n := &NATGateway{
+		helper: config.Helper,
+		logger: config.Logger,
+
+		cloudWatchMetrics: config.CloudWatchMetrics,
+		installationName:  config.InstallationName,
+	}
+
+	return n, nil
+}
+
+func (n *NATGateway) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	err := n.helper.ForEachAccount(ctx, collectorNATGateway, func(acc account) error {
+		err := n.collectForAccount(ctx, ch, acc)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (n *NATGateway) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- natGatewayStateDesc
+	ch <- natGatewayFailureDesc
+	ch <- natGatewayErrorPortAllocationDesc
+	ch <- natGatewayPacketsDropDesc
+	ch <- natGatewayActiveConnectionDesc
+	return nil
+}
+
+// collectForAccount emits the state of the NAT gateways of the
+// installation, and optionally their CloudWatch metrics.
+func (n *NATGateway) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+	i := &ec2.DescribeNatGatewaysInput{
+		Filter: []*ec2.Filter{
+			{
+				Name:   aws.String("tag:" + key.TagInstallation),
+				Values: aws.StringSlice([]string{n.installationName}),
+			},
+		},
+	}
+
+	var gateways []*ec2.NatGateway
+	err := acc.Clients.EC2.DescribeNatGatewaysPagesWithContext(ctx, i, func(o *ec2.DescribeNatGatewaysOutput, lastPage bool) bool {
+		gateways = append(gateways, o.NatGateways...)
+		return true
+	})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var available []string
+	labels := map[string][]string{}
+	for _, g := range gateways {
+		// Deleted gateways are still described for about an hour.
+		state := aws.StringValue(g.State)
+		if state == ec2.NatGatewayStateDeleted {
+			continue
+		}
+
+		cluster, organization := resourceOwner(g.Tags)
+
+		id := aws.StringValue(g.NatGatewayId)
+		labels[id] = []string{
+			acc.ID,
+			acc.Region,
+			id,
+			aws.StringValue(g.VpcId),
+			cluster,
+			n.installationName,
+			organization,
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			natGatewayStateDesc,
+			prometheus.GaugeValue,
+			GaugeValue,
+			append(labels[id], state)...,
+		)
+
+		if state == ec2.NatGatewayStateFailed {
+			ch <- prometheus.MustNewConstMetric(
+				natGatewayFailureDesc,
+				prometheus.GaugeValue,
+				GaugeValue,
+				append(labels[id], natGatewayFailureReason(aws.StringValue(g.FailureCode)))...,
+			)
+		}
+
+		if state == ec2.NatGatewayStateAvailable {
+			available = append(available, id)
+		}
+	}
+
+	if !n.cloudWatchMetrics || len(available) == 0 {
+		return nil
+	}
+
+	values, err := natGatewayMetricValues(ctx, acc.Clients, available, time.Now())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, id := range available {
+		for j, m := range natGatewayMetrics {
+			value, ok := values[natGatewayMetricQueryID(id, j)]
+			if !ok {
+				continue
+			}
+
+			ch <- prometheus.MustNewConstMetric(
+				m.Desc,
+				prometheus.GaugeValue,
+				value,
+				labels[id]...,
+			)
+		}
+	}
+
+	return nil
+}
+
+// natGatewayMetricValues returns the values of the natGatewayMetrics of the
+// given NAT gateways for the last complete period, keyed by query ID. See
+// natGatewayMetricQueryID. Metrics without data points in the period are not
+// part of the result.
+func natGatewayMetricValues(ctx context.Context, awsClients clientaws.Clients, ids []string, now time.Time) (map[string]float64, error) {
+	end := now.Add(-natGatewayMetricsDelay).Truncate(natGatewayMetricsPeriod)
+	start := end.Add(-natGatewayMetricsPeriod)
+
+	queries := natGatewayMetricQueries(ids)
+
+	values := map[string]float64{}
+	for len(queries) > 0 {
+		batchSize := maxMetricDataQueries
+		if len(queries) < batchSize {
+			batchSize = len(queries)
+		}
+
+		i := &cloudwatch.GetMetricDataInput{
+			EndTime:           aws.Time(end),
+			MetricDataQueries: queries[:batchSize],
+			StartTime:         aws.Time(start),
+		}
+		queries = queries[batchSize:]
+
+		var results []*cloudwatch.MetricDataResult
+		err := awsClients.CloudWatch.GetMetricDataPagesWithContext(ctx, i, func(o *cloudwatch.GetMetricDataOutput, lastPage bool) bool {
+			results = append(results, o.MetricDataResults...)
+			return true
+		})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, r := range results {
+			// The period covers the whole time range, so there is at most a
+			// single data point per query.
+			if len(r.Values) == 0 {
+				continue
+			}
+
+			values[aws.StringValue(r.Id)] = aws.Float64Value(r.Values[0])
+		}
+	}
+
+	return values, nil
+}
+
+// natGatewayMetricQueries returns the CloudWatch queries of the
+// natGatewayMetrics of the given NAT gateways.
+func natGatewayMetricQueries(ids []string) []*cloudwatch.MetricDataQuery {
+	var queries []*cloudwatch.MetricDataQuery
+	for _, id := range ids {
+		for j, m := range natGatewayMetrics {
+			queries = append(queries, &cloudwatch.MetricDataQuery{
+				Id: aws.String(natGatewayMetricQueryID(id, j)),
+				MetricStat: &cloudwatch.MetricStat{
+					Metric: &cloudwatch.Metric{
+						Dimensions: []*cloudwatch.Dimension{
+							{
+								Name:  aws.String("NatGatewayId"),
+								Value: aws.String(id),
+							},
+						},
+						MetricName: aws.String(m.Name),
+						Namespace:  aws.String("AWS/NATGateway"),
+					},
+					Period: aws.Int64(int64(natGatewayMetricsPeriod.Seconds())),
+					Stat:   aws.String(m.Statistic),
+				},
+			})
+		}
+	}
+
+	return queries
+}
+
+// natGatewayMetricQueryID returns the ID of the query of the natGatewayMetrics
+// with the given index for the given NAT gateway. Query IDs must start with a
+// lower case letter and must not contain dashes.
+func natGatewayMetricQueryID(id string, metric int) string {
+	return fmt.Sprintf("m%d_%s", metric, strings.ReplaceAll(id, "-", "_"))
+}
+
+// natGatewayFailureReason classifies the given failure code of a failed NAT
+// gateway into one of the natGatewayFailureReasons, or other if it is unknown.
+func natGatewayFailureReason(failureCode string) string {
+	reason, ok := natGatewayFailureReasons[failureCode]
+	if !ok {
+		return natGatewayFailureReasonOther
+	}
+
+	return reason
+}
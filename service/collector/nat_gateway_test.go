@@ -0,0 +1,83 @@
+package collector
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+func TestNATGatewayFailureReason(t *testing.T) {
+	testCases := []struct {
+		name        string
+		failureCode string
+
+		expectedReason string
+	}{
+		{
+			name:        "case 0: associated Elastic IP",
+			failureCode: "Resource.AlreadyAssociated",
+
+			expectedReason: natGatewayFailureReasonEIPUnavailable,
+		},
+		{
+			name:        "case 1: full subnet",
+			failureCode: "InsufficientFreeAddressesInSubnet",
+
+			expectedReason: natGatewayFailureReasonInsufficientIPs,
+		},
+		{
+			name:        "case 2: missing internet gateway",
+			failureCode: "Gateway.NotAttached",
+
+			expectedReason: natGatewayFailureReasonNoInternetGateway,
+		},
+		{
+			name:        "case 3: unknown failure code",
+			failureCode: "SomethingUnexpected",
+
+			expectedReason: natGatewayFailureReasonOther,
+		},
+		{
+			name:        "case 4: missing failure code",
+			failureCode: "",
+
+			expectedReason: natGatewayFailureReasonOther,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			reason := natGatewayFailureReason(tc.failureCode)
+			if reason != tc.expectedReason {
+				t.Fatalf("expected %#q, got %#q", tc.expectedReason, reason)
+			}
+		})
+	}
+}
+
+func TestNATGatewayMetricQueries(t *testing.T) {
+	// CloudWatch requires query IDs to start with a lower case letter and to
+	// only contain letters, numbers and underscores.
+	queryIDRegexp := regexp.MustCompile(`^[a-z][a-zA-Z0-9_]*$`)
+
+	ids := []string{"nat-0123456789abcdef0", "nat-0fedcba9876543210"}
+
+	queries := natGatewayMetricQueries(ids)
+	if len(queries) != len(ids)*len(natGatewayMetrics) {
+		t.Fatalf("expected %d queries, got %d", len(ids)*len(natGatewayMetrics), len(queries))
+	}
+
+	seen := map[string]bool{}
+	for _, q := range queries {
+		id := aws.StringValue(q.Id)
+		if !queryIDRegexp.MatchString(id) {
+			t.Fatalf("expected valid query ID, got %#q", id)
+		}
+		if seen[id] {
+			t.Fatalf("expected unique query IDs, got %#q twice", id)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/giantswarm/microerror"
+	releasev1alpha1 "github.com/giantswarm/release-operator/v4/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/giantswarm/aws-collector/service/controller/key"
+)
+
+var (
+	// flatcarImageNameRegexp matches the names of Flatcar AMIs, both the
+	// official ones like "Flatcar-stable-3510.2.6-hvm" and the ones built by
+	// Giant Swarm like "capa-ami-flatcar-stable-3510.2.6-kube-v1.25.16-gs".
+	flatcarImageNameRegexp = regexp.MustCompile(`(?i)flatcar-[a-z]+-(\d+\.\d+\.\d+)`)
+)
+
+// ClusterOSVersions returns the OS version expected on the nodes of every
+// cluster keyed by cluster ID, based on the containerlinux component of the
+// Release CR the cluster is labelled with. Clusters without release or with
+// unknown release are not part of the result. See clusterOSVersions.
+func (h *helper) ClusterOSVersions(ctx context.Context) (map[string]string, error) {
+	releases, err := h.Releases(ctx)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	clusterReleases, err := h.ClusterReleaseVersions(ctx)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return clusterOSVersions(clusterReleases, releaseOSVersions(releases)), nil
+}
+
+// clusterOSVersions maps the given release versions keyed by cluster to the
+// OS versions keyed by release version. The result is keyed by cluster ID, as
+// AWS resources are only tagged with it. Cluster IDs used in several
+// namespaces with different OS versions are ambiguous and hence not part of
+// the result.
+func clusterOSVersions(clusterReleases map[types.NamespacedName]string, releaseVersions map[string]string) map[string]string {
+	versions := map[string]string{}
+	ambiguous := map[string]bool{}
+
+	for cluster, release := range clusterReleases {
+		v, ok := releaseVersions[release]
+		if !ok {
+			continue
+		}
+
+		if existing, ok := versions[cluster.Name]; ok && existing != v {
+			ambiguous[cluster.Name] = true
+		}
+		versions[cluster.Name] = v
+	}
+
+	for cluster := range ambiguous {
+		delete(versions, cluster)
+	}
+
+	return versions
+}
+
+// releaseOSVersions returns the version of the containerlinux component of
+// the given releases keyed by release version.
+func releaseOSVersions(releases []releasev1alpha1.Release) map[string]string {
+	versions := map[string]string{}
+
+	for _, r := range releases {
+		version, ok := releaseVersion(r.GetName())
+		if !ok {
+			continue
+		}
+
+		for _, c := range r.Spec.Components {
+			if c.Name == key.ComponentOS {
+				versions[version] = c.Version
+			}
+		}
+	}
+
+	return versions
+}
+
+// imageOSVersion returns the Flatcar version of an AMI based on its name, or
+// an empty string if the AMI is not a Flatcar AMI.
+func imageOSVersion(imageName string) string {
+	m := flatcarImageNameRegexp.FindStringSubmatch(imageName)
+	if m == nil {
+		return ""
+	}
+
+	return m[1]
+}
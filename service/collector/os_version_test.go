@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	releasev1alpha1 "github.com/giantswarm/release-operator/v4/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestImageOSVersion(t *testing.T) {
+	testCases := []struct {
+		name      string
+		imageName string
+
+		expectedVersion string
+	}{
+		{
+			name:      "case 0: official Flatcar AMI",
+			imageName: "Flatcar-stable-3510.2.6-hvm",
+
+			expectedVersion: "3510.2.6",
+		},
+		{
+			name:      "case 1: Giant Swarm Flatcar AMI",
+			imageName: "capa-ami-flatcar-stable-3602.2.1-kube-v1.25.16-tooling-1.13.2-gs",
+
+			expectedVersion: "3602.2.1",
+		},
+		{
+			name:      "case 2: other AMI",
+			imageName: "amzn2-ami-kernel-5.10-hvm-2.0.20240412.0-x86_64-gp2",
+
+			expectedVersion: "",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			version := imageOSVersion(tc.imageName)
+
+			if version != tc.expectedVersion {
+				t.Fatalf("expected %#q, got %#q", tc.expectedVersion, version)
+			}
+		})
+	}
+}
+
+func TestClusterOSVersions(t *testing.T) {
+	releases := []releasev1alpha1.Release{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "v19.3.0",
+			},
+			Spec: releasev1alpha1.ReleaseSpec{
+				Components: []releasev1alpha1.ReleaseSpecComponent{
+					{Name: "kubernetes", Version: "1.25.16"},
+					{Name: "containerlinux", Version: "3510.2.6"},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "aws-25.0.0",
+			},
+			Spec: releasev1alpha1.ReleaseSpec{
+				Components: []releasev1alpha1.ReleaseSpecComponent{
+					{Name: "kubernetes", Version: "1.25.16"},
+					{Name: "containerlinux", Version: "3602.2.1"},
+				},
+			},
+		},
+	}
+
+	clusterReleases := map[types.NamespacedName]string{
+		{Namespace: "org-acme", Name: "a1b2c"}: "19.3.0",
+		{Namespace: "org-acme", Name: "d3e4f"}: "25.0.0",
+		{Namespace: "org-acme", Name: "g5h6i"}: "26.0.0",
+		{Namespace: "org-acme", Name: "j7k8l"}: "",
+		// Same cluster ID in several namespaces with the same OS version.
+		{Namespace: "org-acme", Name: "m9n0o"}:  "19.3.0",
+		{Namespace: "org-other", Name: "m9n0o"}: "19.3.0",
+		// Same cluster ID in several namespaces with different OS versions.
+		{Namespace: "org-acme", Name: "p1q2r"}:  "19.3.0",
+		{Namespace: "org-other", Name: "p1q2r"}: "25.0.0",
+	}
+
+	expected := map[string]string{
+		"a1b2c": "3510.2.6",
+		"d3e4f": "3602.2.1",
+		"m9n0o": "3510.2.6",
+	}
+
+	versions := clusterOSVersions(clusterReleases, releaseOSVersions(releases))
+
+	if !reflect.DeepEqual(versions, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, versions)
+	}
+}
@@ -0,0 +1,1046 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	clientaws "github.com/giantswarm/aws-collector/client/aws"
+	"github.com/giantswarm/aws-collector/service/controller/key"
+	"github.com/giantswarm/aws-collector/service/internal/cache"
+)
+
+const (
+	testInstallation = "test"
+)
+
+var (
+	fqNameRegexp = regexp.MustCompile(`fqName: "([^"]+)"`)
+)
+
+// TestCollectorsFollowAllPages feeds every collector listing AWS resources
+// with several pages of resources, including an empty one, and verifies that
+// the resources of all pages are reported. The sums of the given metrics
+// equal the number of resources, so a lost page shows up as a lower sum.
+func TestCollectorsFollowAllPages(t *testing.T) {
+	testCases := []struct {
+		name    string
+		fakes   fakeClients
+		collect func(ctx context.Context, ch chan<- prometheus.Metric, acc account) error
+
+		expectedSums map[string]float64
+	}{
+		{
+			name: "case 0: asg",
+			fakes: fakeClients{
+				AutoScaling: &fakeAutoScaling{
+					groups: [][]*autoscaling.Group{
+						{
+							{
+								AutoScalingGroupName: aws.String("asg-1"),
+								DesiredCapacity:      aws.Int64(1),
+								MaxSize:              aws.Int64(1),
+								MinSize:              aws.Int64(1),
+								Tags: []*autoscaling.TagDescription{
+									{Key: aws.String(key.TagInstallation), Value: aws.String(testInstallation)},
+								},
+							},
+							{
+								AutoScalingGroupName: aws.String("asg-2"),
+								DesiredCapacity:      aws.Int64(1),
+								MaxSize:              aws.Int64(1),
+								MinSize:              aws.Int64(1),
+								Tags: []*autoscaling.TagDescription{
+									{Key: aws.String(key.TagInstallation), Value: aws.String(testInstallation)},
+								},
+							},
+						},
+						{},
+						{
+							{
+								AutoScalingGroupName: aws.String("asg-3"),
+								DesiredCapacity:      aws.Int64(1),
+								MaxSize:              aws.Int64(1),
+								MinSize:              aws.Int64(1),
+								Tags: []*autoscaling.TagDescription{
+									{Key: aws.String(key.TagInstallation), Value: aws.String(testInstallation)},
+								},
+							},
+						},
+					},
+				},
+			},
+			collect: func(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+				c, err := NewASG(ASGConfig{Helper: &helper{}, Logger: microloggertest.New(), InstallationName: testInstallation})
+				if err != nil {
+					return err
+				}
+				return c.collectForAccount(ctx, ch, acc)
+			},
+
+			expectedSums: map[string]float64{
+				"aws_operator_asg_desired_count": 3,
+			},
+		},
+		{
+			name: "case 1: cloudformation",
+			fakes: fakeClients{
+				CloudFormation: &fakeCloudFormation{
+					stacks: [][]*cloudformation.StackSummary{
+						{
+							{
+								CreationTime: aws.Time(time.Now()),
+								StackId:      aws.String("arn:aws:cloudformation:eu-west-1:123456789012:stack/cluster-a1b2c-tccp/1"),
+								StackName:    aws.String("cluster-a1b2c-tccp"),
+								StackStatus:  aws.String(cloudformation.StackStatusCreateComplete),
+							},
+							{
+								CreationTime: aws.Time(time.Now()),
+								StackId:      aws.String("arn:aws:cloudformation:eu-west-1:123456789012:stack/unrelated/1"),
+								StackName:    aws.String("unrelated"),
+								StackStatus:  aws.String(cloudformation.StackStatusCreateComplete),
+							},
+						},
+						{},
+						{
+							{
+								CreationTime: aws.Time(time.Now()),
+								StackId:      aws.String("arn:aws:cloudformation:eu-west-1:123456789012:stack/cluster-a1b2c-tcnp-d3e4f/1"),
+								StackName:    aws.String("cluster-a1b2c-tcnp-d3e4f"),
+								StackStatus:  aws.String(cloudformation.StackStatusCreateComplete),
+							},
+						},
+					},
+				},
+			},
+			collect: func(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+				c, err := NewCloudFormation(CloudFormationConfig{Helper: &helper{}, Logger: microloggertest.New(), InstallationName: testInstallation})
+				if err != nil {
+					return err
+				}
+				return c.collectForAccount(ctx, ch, acc)
+			},
+
+			expectedSums: map[string]float64{
+				"aws_operator_cloudformation_info": 2,
+			},
+		},
+		{
+			name: "case 2: ebs",
+			fakes: fakeClients{
+				EC2: &fakeEC2{
+					// Every volume belongs to another cluster, so that it is
+					// reported on its own.
+					volumes: [][]*ec2.Volume{
+						{
+							{
+								Size:       aws.Int64(1),
+								State:      aws.String(ec2.VolumeStateInUse),
+								Tags:       append(newTestInstallationTags(), &ec2.Tag{Key: aws.String(tagCluster), Value: aws.String("vol-1")}),
+								VolumeId:   aws.String("vol-1"),
+								VolumeType: aws.String(ec2.VolumeTypeGp3),
+							},
+							{
+								Size:       aws.Int64(1),
+								State:      aws.String(ec2.VolumeStateInUse),
+								Tags:       append(newTestInstallationTags(), &ec2.Tag{Key: aws.String(tagCluster), Value: aws.String("vol-2")}),
+								VolumeId:   aws.String("vol-2"),
+								VolumeType: aws.String(ec2.VolumeTypeGp3),
+							},
+						},
+						{},
+						{
+							{
+								Size:       aws.Int64(1),
+								State:      aws.String(ec2.VolumeStateInUse),
+								Tags:       append(newTestInstallationTags(), &ec2.Tag{Key: aws.String(tagCluster), Value: aws.String("vol-3")}),
+								VolumeId:   aws.String("vol-3"),
+								VolumeType: aws.String(ec2.VolumeTypeGp3),
+							},
+						},
+					},
+				},
+			},
+			collect: func(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+				c, err := NewEBS(EBSConfig{Helper: &helper{}, Logger: microloggertest.New(), InstallationName: testInstallation})
+				if err != nil {
+					return err
+				}
+				return c.collectForAccount(ctx, ch, acc)
+			},
+
+			expectedSums: map[string]float64{
+				"aws_operator_ebs_volume_count": 3,
+			},
+		},
+		{
+			name: "case 3: ec2instances",
+			fakes: fakeClients{
+				EC2: &fakeEC2{
+					instances: [][]*ec2.Instance{
+						{
+							{
+								InstanceId:     aws.String("i-1"),
+								InstanceType:   aws.String("m5.xlarge"),
+								PrivateDnsName: aws.String("i-1.eu-west-1.compute.internal"),
+								State:          &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+								Tags:           newTestInstallationTags(),
+							},
+							{
+								InstanceId:     aws.String("i-2"),
+								InstanceType:   aws.String("m5.xlarge"),
+								PrivateDnsName: aws.String("i-2.eu-west-1.compute.internal"),
+								State:          &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+								Tags:           newTestInstallationTags(),
+							},
+						},
+						{},
+						{
+							{
+								InstanceId:     aws.String("i-3"),
+								InstanceType:   aws.String("m5.xlarge"),
+								PrivateDnsName: aws.String("i-3.eu-west-1.compute.internal"),
+								State:          &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+								Tags:           newTestInstallationTags(),
+							},
+						},
+					},
+					instanceStatuses: [][]*ec2.InstanceStatus{
+						{
+							{
+								InstanceId:     aws.String("i-1"),
+								InstanceState:  &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+								InstanceStatus: &ec2.InstanceStatusSummary{Status: aws.String(ec2.SummaryStatusOk)},
+							},
+						},
+						{
+							{
+								InstanceId:     aws.String("i-2"),
+								InstanceState:  &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+								InstanceStatus: &ec2.InstanceStatusSummary{Status: aws.String(ec2.SummaryStatusOk)},
+							},
+							{
+								InstanceId:     aws.String("i-3"),
+								InstanceState:  &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+								InstanceStatus: &ec2.InstanceStatusSummary{Status: aws.String(ec2.SummaryStatusOk)},
+							},
+						},
+					},
+					instanceTypes: [][]*ec2.InstanceTypeInfo{
+						{},
+						{{InstanceType: aws.String("m5.xlarge"), VCpuInfo: &ec2.VCpuInfo{DefaultVCpus: aws.Int64(4)}}},
+					},
+				},
+				ServiceQuotas: &fakeServiceQuotas{},
+			},
+			collect: func(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+				h := &helper{
+					quotaCache:        cache.NewCache[map[string]serviceQuota](time.Hour),
+					runningVCPUsCache: cache.NewCache[map[string]int64](time.Hour),
+					vcpuCache:         cache.NewCache[int64](time.Hour),
+				}
+				c, err := NewEC2Instances(EC2InstancesConfig{Helper: h, Logger: microloggertest.New(), InstallationName: testInstallation})
+				if err != nil {
+					return err
+				}
+				return c.collectForAccount(ctx, ch, acc, nil)
+			},
+
+			expectedSums: map[string]float64{
+				"aws_operator_ec2_instance_status": 3,
+				"aws_operator_ec2_running_vcpus":   12,
+				"aws_operator_ec2_vcpu_quota":      1,
+			},
+		},
+		{
+			name: "case 4: elb",
+			fakes: fakeClients{
+				ELB: &fakeELB{
+					loadBalancers: [][]*elb.LoadBalancerDescription{
+						{{LoadBalancerName: aws.String("elb-1")}, {LoadBalancerName: aws.String("elb-2")}},
+						{},
+						{{LoadBalancerName: aws.String("elb-3")}},
+					},
+				},
+			},
+			collect: func(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+				c, err := NewELB(ELBConfig{Helper: &helper{}, Logger: microloggertest.New(), InstallationName: testInstallation})
+				if err != nil {
+					return err
+				}
+				return c.collectForAccount(ctx, ch, acc)
+			},
+
+			expectedSums: map[string]float64{
+				"aws_operator_elb_instance_out_of_service_count": 3,
+			},
+		},
+		{
+			name: "case 5: elbv2",
+			fakes: fakeClients{
+				ELBv2: &fakeELBv2{
+					limits: [][]*elbv2.Limit{
+						{{Name: aws.String("application-load-balancers"), Max: aws.String("1")}},
+						{{Name: aws.String("network-load-balancers"), Max: aws.String("1")}},
+					},
+					listeners: [][]*elbv2.Listener{
+						{{}},
+						{{}},
+					},
+					loadBalancers: [][]*elbv2.LoadBalancer{
+						{
+							{
+								LoadBalancerArn:  aws.String("arn:aws:elasticloadbalancing:eu-west-1:123456789012:loadbalancer/net/lb-1/1"),
+								LoadBalancerName: aws.String("lb-1"),
+								State:            &elbv2.LoadBalancerState{Code: aws.String(elbv2.LoadBalancerStateEnumActive)},
+								Type:             aws.String(elbv2.LoadBalancerTypeEnumNetwork),
+							},
+							{
+								LoadBalancerArn:  aws.String("arn:aws:elasticloadbalancing:eu-west-1:123456789012:loadbalancer/net/lb-2/1"),
+								LoadBalancerName: aws.String("lb-2"),
+								State:            &elbv2.LoadBalancerState{Code: aws.String(elbv2.LoadBalancerStateEnumActive)},
+								Type:             aws.String(elbv2.LoadBalancerTypeEnumNetwork),
+							},
+						},
+						{},
+						{
+							{
+								LoadBalancerArn:  aws.String("arn:aws:elasticloadbalancing:eu-west-1:123456789012:loadbalancer/net/lb-3/1"),
+								LoadBalancerName: aws.String("lb-3"),
+								State:            &elbv2.LoadBalancerState{Code: aws.String(elbv2.LoadBalancerStateEnumActive)},
+								Type:             aws.String(elbv2.LoadBalancerTypeEnumNetwork),
+							},
+						},
+					},
+					targetGroups: [][]*elbv2.TargetGroup{
+						{{TargetGroupName: aws.String("tg-1")}},
+						{{TargetGroupName: aws.String("tg-2")}},
+					},
+				},
+			},
+			collect: func(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+				c, err := NewELBv2(ELBv2Config{Helper: &helper{}, Logger: microloggertest.New(), InstallationName: testInstallation})
+				if err != nil {
+					return err
+				}
+				return c.collectForAccount(ctx, ch, acc)
+			},
+
+			expectedSums: map[string]float64{
+				"aws_operator_elbv2_limit":               2,
+				"aws_operator_elbv2_listener_count":      6,
+				"aws_operator_elbv2_load_balancer_count": 3,
+				"aws_operator_elbv2_load_balancer_state": 3,
+				"aws_operator_elbv2_target_group_count":  2,
+			},
+		},
+		{
+			name: "case 6: nat",
+			fakes: fakeClients{
+				EC2: &fakeEC2{
+					natGateways: [][]*ec2.NatGateway{
+						{
+							{
+								NatGatewayId: aws.String("nat-1"),
+								State:        aws.String(ec2.NatGatewayStateAvailable),
+								SubnetId:     aws.String("subnet-1"),
+								Tags:         newTestInstallationTags(),
+								VpcId:        aws.String("vpc-1"),
+							},
+							{
+								NatGatewayId: aws.String("nat-2"),
+								State:        aws.String(ec2.NatGatewayStateAvailable),
+								SubnetId:     aws.String("subnet-2"),
+								Tags:         newTestInstallationTags(),
+								VpcId:        aws.String("vpc-1"),
+							},
+						},
+						{},
+						{
+							{
+								NatGatewayId: aws.String("nat-3"),
+								State:        aws.String(ec2.NatGatewayStateAvailable),
+								SubnetId:     aws.String("subnet-3"),
+								Tags:         newTestInstallationTags(),
+								VpcId:        aws.String("vpc-1"),
+							},
+						},
+					},
+					subnets: [][]*ec2.Subnet{
+						{
+							{
+								AvailabilityZone:        aws.String("eu-west-1a"),
+								AvailabilityZoneId:      aws.String("euw1-az1"),
+								AvailableIpAddressCount: aws.Int64(1),
+								CidrBlock:               aws.String("10.0.0.0/24"),
+								OwnerId:                 aws.String("123456789012"),
+								State:                   aws.String(ec2.SubnetStateAvailable),
+								SubnetId:                aws.String("subnet-1"),
+								Tags:                    newTestInstallationTags(),
+								VpcId:                   aws.String("vpc-1"),
+							},
+							{
+								AvailabilityZone:        aws.String("eu-west-1b"),
+								AvailabilityZoneId:      aws.String("euw1-az2"),
+								AvailableIpAddressCount: aws.Int64(1),
+								CidrBlock:               aws.String("10.0.0.0/24"),
+								OwnerId:                 aws.String("123456789012"),
+								State:                   aws.String(ec2.SubnetStateAvailable),
+								SubnetId:                aws.String("subnet-2"),
+								Tags:                    newTestInstallationTags(),
+								VpcId:                   aws.String("vpc-1"),
+							},
+						},
+						{
+							{
+								AvailabilityZone:        aws.String("eu-west-1c"),
+								AvailabilityZoneId:      aws.String("euw1-az3"),
+								AvailableIpAddressCount: aws.Int64(1),
+								CidrBlock:               aws.String("10.0.0.0/24"),
+								OwnerId:                 aws.String("123456789012"),
+								State:                   aws.String(ec2.SubnetStateAvailable),
+								SubnetId:                aws.String("subnet-3"),
+								Tags:                    newTestInstallationTags(),
+								VpcId:                   aws.String("vpc-1"),
+							},
+						},
+					},
+					vpcs: [][]*ec2.Vpc{
+						{},
+						{
+							{
+								CidrBlock: aws.String("10.0.0.0/16"),
+								State:     aws.String(ec2.VpcStateAvailable),
+								Tags:      newTestInstallationTags(),
+								VpcId:     aws.String("vpc-1"),
+							},
+						},
+					},
+				},
+			},
+			collect: func(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+				c, err := NewNAT(NATConfig{Helper: &helper{}, Logger: microloggertest.New(), InstallationName: testInstallation})
+				if err != nil {
+					return err
+				}
+				return c.collectForAccount(ctx, ch, acc)
+			},
+
+			expectedSums: map[string]float64{
+				"aws_operator_nat_info": 3,
+			},
+		},
+		{
+			name: "case 7: subnet",
+			fakes: fakeClients{
+				EC2: &fakeEC2{
+					// Every subnet is located in another zone and has a single
+					// available IP address.
+					subnets: [][]*ec2.Subnet{
+						{
+							{
+								AvailabilityZone:        aws.String("eu-west-1a"),
+								AvailabilityZoneId:      aws.String("euw1-az1"),
+								AvailableIpAddressCount: aws.Int64(1),
+								CidrBlock:               aws.String("10.0.0.0/24"),
+								OwnerId:                 aws.String("123456789012"),
+								State:                   aws.String(ec2.SubnetStateAvailable),
+								SubnetId:                aws.String("subnet-1"),
+								Tags:                    newTestInstallationTags(),
+								VpcId:                   aws.String("vpc-1"),
+							},
+							{
+								AvailabilityZone:        aws.String("eu-west-1b"),
+								AvailabilityZoneId:      aws.String("euw1-az2"),
+								AvailableIpAddressCount: aws.Int64(1),
+								CidrBlock:               aws.String("10.0.0.0/24"),
+								OwnerId:                 aws.String("123456789012"),
+								State:                   aws.String(ec2.SubnetStateAvailable),
+								SubnetId:                aws.String("subnet-2"),
+								Tags:                    newTestInstallationTags(),
+								VpcId:                   aws.String("vpc-1"),
+							},
+						},
+						{},
+						{
+							{
+								AvailabilityZone:        aws.String("eu-west-1c"),
+								AvailabilityZoneId:      aws.String("euw1-az3"),
+								AvailableIpAddressCount: aws.Int64(1),
+								CidrBlock:               aws.String("10.0.0.0/24"),
+								OwnerId:                 aws.String("123456789012"),
+								State:                   aws.String(ec2.SubnetStateAvailable),
+								SubnetId:                aws.String("subnet-3"),
+								Tags:                    newTestInstallationTags(),
+								VpcId:                   aws.String("vpc-1"),
+							},
+						},
+					},
+				},
+			},
+			collect: func(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+				c, err := NewSubnet(SubnetConfig{Helper: &helper{}, Logger: microloggertest.New(), InstallationName: testInstallation})
+				if err != nil {
+					return err
+				}
+				return c.collectForAccount(ctx, ch, acc)
+			},
+
+			expectedSums: map[string]float64{
+				"aws_operator_subnet_available_ips": 3,
+			},
+		},
+		{
+			name: "case 8: vpc",
+			fakes: fakeClients{
+				EC2: &fakeEC2{
+					vpcs: [][]*ec2.Vpc{
+						{
+							{
+								CidrBlock: aws.String("10.0.0.0/16"),
+								State:     aws.String(ec2.VpcStateAvailable),
+								Tags:      newTestInstallationTags(),
+								VpcId:     aws.String("vpc-1"),
+							},
+							{
+								CidrBlock: aws.String("10.0.0.0/16"),
+								State:     aws.String(ec2.VpcStateAvailable),
+								Tags:      newTestInstallationTags(),
+								VpcId:     aws.String("vpc-2"),
+							},
+						},
+						{},
+						{
+							{
+								CidrBlock: aws.String("10.0.0.0/16"),
+								State:     aws.String(ec2.VpcStateAvailable),
+								Tags:      newTestInstallationTags(),
+								VpcId:     aws.String("vpc-3"),
+							},
+						},
+					},
+				},
+			},
+			collect: func(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+				c, err := NewVPC(VPCConfig{Helper: &helper{}, Logger: microloggertest.New(), InstallationName: testInstallation})
+				if err != nil {
+					return err
+				}
+				return c.collectForAccount(ctx, ch, acc)
+			},
+
+			expectedSums: map[string]float64{
+				"aws_operator_vpc_info": 3,
+			},
+		},
+		{
+			name: "case 9: natgateway with cloudwatch metrics",
+			fakes: fakeClients{
+				CloudWatch: &fakeCloudWatch{
+					pages: 3,
+				},
+				EC2: &fakeEC2{
+					natGateways: [][]*ec2.NatGateway{
+						{
+							{
+								NatGatewayId: aws.String("nat-1"),
+								State:        aws.String(ec2.NatGatewayStateAvailable),
+								SubnetId:     aws.String("subnet-1"),
+								Tags:         newTestInstallationTags(),
+								VpcId:        aws.String("vpc-1"),
+							},
+							{
+								NatGatewayId: aws.String("nat-2"),
+								State:        aws.String(ec2.NatGatewayStateAvailable),
+								SubnetId:     aws.String("subnet-2"),
+								Tags:         newTestInstallationTags(),
+								VpcId:        aws.String("vpc-1"),
+							},
+						},
+						{},
+						{
+							{
+								NatGatewayId: aws.String("nat-3"),
+								State:        aws.String(ec2.NatGatewayStateAvailable),
+								SubnetId:     aws.String("subnet-3"),
+								Tags:         newTestInstallationTags(),
+								VpcId:        aws.String("vpc-1"),
+							},
+						},
+					},
+					subnets: [][]*ec2.Subnet{
+						{
+							{
+								AvailabilityZone:        aws.String("eu-west-1a"),
+								AvailabilityZoneId:      aws.String("euw1-az1"),
+								AvailableIpAddressCount: aws.Int64(1),
+								CidrBlock:               aws.String("10.0.0.0/24"),
+								OwnerId:                 aws.String("123456789012"),
+								State:                   aws.String(ec2.SubnetStateAvailable),
+								SubnetId:                aws.String("subnet-1"),
+								Tags:                    newTestInstallationTags(),
+								VpcId:                   aws.String("vpc-1"),
+							},
+							{
+								AvailabilityZone:        aws.String("eu-west-1b"),
+								AvailabilityZoneId:      aws.String("euw1-az2"),
+								AvailableIpAddressCount: aws.Int64(1),
+								CidrBlock:               aws.String("10.0.0.0/24"),
+								OwnerId:                 aws.String("123456789012"),
+								State:                   aws.String(ec2.SubnetStateAvailable),
+								SubnetId:                aws.String("subnet-2"),
+								Tags:                    newTestInstallationTags(),
+								VpcId:                   aws.String("vpc-1"),
+							},
+						},
+						{
+							{
+								AvailabilityZone:        aws.String("eu-west-1c"),
+								AvailabilityZoneId:      aws.String("euw1-az3"),
+								AvailableIpAddressCount: aws.Int64(1),
+								CidrBlock:               aws.String("10.0.0.0/24"),
+								OwnerId:                 aws.String("123456789012"),
+								State:                   aws.String(ec2.SubnetStateAvailable),
+								SubnetId:                aws.String("subnet-3"),
+								Tags:                    newTestInstallationTags(),
+								VpcId:                   aws.String("vpc-1"),
+							},
+						},
+					},
+				},
+			},
+			collect: func(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+				c, err := NewNATGateway(NATGatewayConfig{Helper: &helper{}, Logger: microloggertest.New(), CloudWatchMetrics: true, InstallationName: testInstallation})
+				if err != nil {
+					return err
+				}
+				return c.collectForAccount(ctx, ch, acc)
+			},
+
+			expectedSums: map[string]float64{
+				"aws_operator_nat_gateway_active_connection_count":     3,
+				"aws_operator_nat_gateway_error_port_allocation_count": 3,
+				"aws_operator_nat_gateway_packets_drop_count":          3,
+				"aws_operator_nat_gateway_state":                       3,
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			acc := account{
+				ID:      "123456789012",
+				Region:  "eu-west-1",
+				Clients: newFakeClients(tc.fakes),
+			}
+
+			ch := make(chan prometheus.Metric, 1000)
+			err := tc.collect(context.Background(), ch, acc)
+			if err != nil {
+				t.Fatalf("error == %#v, want nil", err)
+			}
+			close(ch)
+
+			sums := map[string]float64{}
+			for m := range ch {
+				var d dto.Metric
+				err := m.Write(&d)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				sums[fqNameRegexp.FindStringSubmatch(m.Desc().String())[1]] += d.GetGauge().GetValue()
+			}
+
+			for name, expected := range tc.expectedSums {
+				if sums[name] != expected {
+					t.Fatalf("expected %s to sum up to %v, got %v", name, expected, sums[name])
+				}
+			}
+		})
+	}
+}
+
+// fakePage returns the page of the given pages requested by the given token,
+// which is the index of the page, together with the token of the next page.
+func fakePage[T any](pages [][]T, token *string) ([]T, *string, error) {
+	var i int
+	if token != nil {
+		var err error
+		i, err = strconv.Atoi(*token)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if i >= len(pages) {
+		if i == 0 {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("unknown page token %#q", *token)
+	}
+
+	var next *string
+	if i+1 < len(pages) {
+		next = aws.String(strconv.Itoa(i + 1))
+	}
+
+	return pages[i], next, nil
+}
+
+// fakeClients holds the fakes answering the requests of the AWS clients
+// created by newFakeClients.
+type fakeClients struct {
+	AutoScaling    *fakeAutoScaling
+	CloudFormation *fakeCloudFormation
+	CloudWatch     *fakeCloudWatch
+	EC2            *fakeEC2
+	ELB            *fakeELB
+	ELBv2          *fakeELBv2
+	ServiceQuotas  *fakeServiceQuotas
+}
+
+// newFakeClients returns real AWS clients whose requests are answered by the
+// given fakes instead of being sent, so that the paginators of the AWS SDK
+// are used as in production.
+func newFakeClients(fakes fakeClients) clientaws.Clients {
+	return clientaws.Clients{
+		AutoScaling:    autoscaling.New(newFakeSession(fakes.AutoScaling)),
+		CloudFormation: cloudformation.New(newFakeSession(fakes.CloudFormation)),
+		CloudWatch:     cloudwatch.New(newFakeSession(fakes.CloudWatch)),
+		EC2:            ec2.New(newFakeSession(fakes.EC2)),
+		ELB:            elb.New(newFakeSession(fakes.ELB)),
+		ELBv2:          elbv2.New(newFakeSession(fakes.ELBv2)),
+		ServiceQuotas:  servicequotas.New(newFakeSession(fakes.ServiceQuotas)),
+	}
+}
+
+// newFakeSession returns a session answering every request by calling the
+// method of the given fake named after the operation with the WithContext
+// suffix, e.g. DescribeVpcsWithContext. Requests of operations the fake does
+// not implement fail.
+func newFakeSession(fake interface{}) *session.Session {
+	s := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+		Region:      aws.String("eu-west-1"),
+	}))
+
+	s.Handlers.Send.Clear()
+	s.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}
+
+		v := reflect.ValueOf(fake)
+		if v.IsNil() {
+			r.Error = fmt.Errorf("unexpected operation %#q", r.Operation.Name)
+			return
+		}
+		m := v.MethodByName(r.Operation.Name + "WithContext")
+		if !m.IsValid() {
+			r.Error = fmt.Errorf("unexpected operation %#q", r.Operation.Name)
+			return
+		}
+
+		out := m.Call([]reflect.Value{reflect.ValueOf(r.Context()), reflect.ValueOf(r.Params)})
+		if !out[1].IsNil() {
+			r.Error = out[1].Interface().(error)
+			return
+		}
+		reflect.ValueOf(r.Data).Elem().Set(out[0].Elem())
+	})
+	s.Handlers.UnmarshalMeta.Clear()
+	s.Handlers.Unmarshal.Clear()
+	s.Handlers.UnmarshalError.Clear()
+	s.Handlers.ValidateResponse.Clear()
+
+	return s
+}
+
+func newTestInstallationTags() []*ec2.Tag {
+	return []*ec2.Tag{
+		{Key: aws.String(key.TagInstallation), Value: aws.String(testInstallation)},
+	}
+}
+
+type fakeAutoScaling struct {
+	activities [][]*autoscaling.Activity
+	groups     [][]*autoscaling.Group
+}
+
+func (f *fakeAutoScaling) DescribeAutoScalingGroupsWithContext(ctx aws.Context, input *autoscaling.DescribeAutoScalingGroupsInput, opts ...request.Option) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	page, next, err := fakePage(f.groups, input.NextToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &autoscaling.DescribeAutoScalingGroupsOutput{AutoScalingGroups: page, NextToken: next}, nil
+}
+
+func (f *fakeAutoScaling) DescribeInstanceRefreshesWithContext(ctx aws.Context, input *autoscaling.DescribeInstanceRefreshesInput, opts ...request.Option) (*autoscaling.DescribeInstanceRefreshesOutput, error) {
+	return &autoscaling.DescribeInstanceRefreshesOutput{}, nil
+}
+
+func (f *fakeAutoScaling) DescribeScalingActivitiesWithContext(ctx aws.Context, input *autoscaling.DescribeScalingActivitiesInput, opts ...request.Option) (*autoscaling.DescribeScalingActivitiesOutput, error) {
+	page, next, err := fakePage(f.activities, input.NextToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &autoscaling.DescribeScalingActivitiesOutput{Activities: page, NextToken: next}, nil
+}
+
+type fakeCloudFormation struct {
+	stacks [][]*cloudformation.StackSummary
+}
+
+func (f *fakeCloudFormation) DescribeStacksWithContext(ctx aws.Context, input *cloudformation.DescribeStacksInput, opts ...request.Option) (*cloudformation.DescribeStacksOutput, error) {
+	stack := &cloudformation.Stack{
+		StackId: input.StackName,
+		Tags: []*cloudformation.Tag{
+			{Key: aws.String(key.TagInstallation), Value: aws.String(testInstallation)},
+			{Key: aws.String(key.TagStack), Value: aws.String(key.StackTCCP)},
+		},
+	}
+
+	return &cloudformation.DescribeStacksOutput{Stacks: []*cloudformation.Stack{stack}}, nil
+}
+
+func (f *fakeCloudFormation) ListStacksWithContext(ctx aws.Context, input *cloudformation.ListStacksInput, opts ...request.Option) (*cloudformation.ListStacksOutput, error) {
+	page, next, err := fakePage(f.stacks, input.NextToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cloudformation.ListStacksOutput{StackSummaries: page, NextToken: next}, nil
+}
+
+type fakeCloudWatch struct {
+	pages int
+}
+
+func (f *fakeCloudWatch) GetMetricDataWithContext(ctx aws.Context, input *cloudwatch.GetMetricDataInput, opts ...request.Option) (*cloudwatch.GetMetricDataOutput, error) {
+	var i int
+	if input.NextToken != nil {
+		var err error
+		i, err = strconv.Atoi(*input.NextToken)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The results of every query are split across all pages, with the single
+	// data point of the query being part of the last page only.
+	o := &cloudwatch.GetMetricDataOutput{}
+	for _, q := range input.MetricDataQueries {
+		r := &cloudwatch.MetricDataResult{Id: q.Id}
+		if i+1 == f.pages {
+			r.Values = aws.Float64Slice([]float64{1})
+		}
+		o.MetricDataResults = append(o.MetricDataResults, r)
+	}
+	if i+1 < f.pages {
+		o.NextToken = aws.String(strconv.Itoa(i + 1))
+	}
+
+	return o, nil
+}
+
+type fakeEC2 struct {
+	instances        [][]*ec2.Instance
+	instanceStatuses [][]*ec2.InstanceStatus
+	instanceTypes    [][]*ec2.InstanceTypeInfo
+	natGateways      [][]*ec2.NatGateway
+	subnets          [][]*ec2.Subnet
+	volumes          [][]*ec2.Volume
+	vpcs             [][]*ec2.Vpc
+}
+
+func (f *fakeEC2) DescribeImagesWithContext(ctx aws.Context, input *ec2.DescribeImagesInput, opts ...request.Option) (*ec2.DescribeImagesOutput, error) {
+	return &ec2.DescribeImagesOutput{}, nil
+}
+
+func (f *fakeEC2) DescribeInstancesWithContext(ctx aws.Context, input *ec2.DescribeInstancesInput, opts ...request.Option) (*ec2.DescribeInstancesOutput, error) {
+	page, next, err := fakePage(f.instances, input.NextToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{{Instances: page}}, NextToken: next}, nil
+}
+
+func (f *fakeEC2) DescribeInstanceStatusWithContext(ctx aws.Context, input *ec2.DescribeInstanceStatusInput, opts ...request.Option) (*ec2.DescribeInstanceStatusOutput, error) {
+	page, next, err := fakePage(f.instanceStatuses, input.NextToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ec2.DescribeInstanceStatusOutput{InstanceStatuses: page, NextToken: next}, nil
+}
+
+func (f *fakeEC2) DescribeInstanceTypesWithContext(ctx aws.Context, input *ec2.DescribeInstanceTypesInput, opts ...request.Option) (*ec2.DescribeInstanceTypesOutput, error) {
+	page, next, err := fakePage(f.instanceTypes, input.NextToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ec2.DescribeInstanceTypesOutput{InstanceTypes: page, NextToken: next}, nil
+}
+
+func (f *fakeEC2) DescribeNatGatewaysWithContext(ctx aws.Context, input *ec2.DescribeNatGatewaysInput, opts ...request.Option) (*ec2.DescribeNatGatewaysOutput, error) {
+	page, next, err := fakePage(f.natGateways, input.NextToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ec2.DescribeNatGatewaysOutput{NatGateways: page, NextToken: next}, nil
+}
+
+func (f *fakeEC2) DescribeSubnetsWithContext(ctx aws.Context, input *ec2.DescribeSubnetsInput, opts ...request.Option) (*ec2.DescribeSubnetsOutput, error) {
+	page, next, err := fakePage(f.subnets, input.NextToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ec2.DescribeSubnetsOutput{Subnets: page, NextToken: next}, nil
+}
+
+func (f *fakeEC2) DescribeVolumesWithContext(ctx aws.Context, input *ec2.DescribeVolumesInput, opts ...request.Option) (*ec2.DescribeVolumesOutput, error) {
+	page, next, err := fakePage(f.volumes, input.NextToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ec2.DescribeVolumesOutput{Volumes: page, NextToken: next}, nil
+}
+
+func (f *fakeEC2) DescribeVpcsWithContext(ctx aws.Context, input *ec2.DescribeVpcsInput, opts ...request.Option) (*ec2.DescribeVpcsOutput, error) {
+	page, next, err := fakePage(f.vpcs, input.NextToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ec2.DescribeVpcsOutput{Vpcs: page, NextToken: next}, nil
+}
+
+type fakeELB struct {
+	loadBalancers [][]*elb.LoadBalancerDescription
+}
+
+func (f *fakeELB) DescribeInstanceHealthWithContext(ctx aws.Context, input *elb.DescribeInstanceHealthInput, opts ...request.Option) (*elb.DescribeInstanceHealthOutput, error) {
+	// Every load balancer has a single instance out of service.
+	return &elb.DescribeInstanceHealthOutput{
+		InstanceStates: []*elb.InstanceState{{State: aws.String(stateOutOfService)}},
+	}, nil
+}
+
+func (f *fakeELB) DescribeLoadBalancersWithContext(ctx aws.Context, input *elb.DescribeLoadBalancersInput, opts ...request.Option) (*elb.DescribeLoadBalancersOutput, error) {
+	page, next, err := fakePage(f.loadBalancers, input.Marker)
+	if err != nil {
+		return nil, err
+	}
+
+	return &elb.DescribeLoadBalancersOutput{LoadBalancerDescriptions: page, NextMarker: next}, nil
+}
+
+func (f *fakeELB) DescribeTagsWithContext(ctx aws.Context, input *elb.DescribeTagsInput, opts ...request.Option) (*elb.DescribeTagsOutput, error) {
+	o := &elb.DescribeTagsOutput{}
+	for _, name := range input.LoadBalancerNames {
+		o.TagDescriptions = append(o.TagDescriptions, &elb.TagDescription{
+			LoadBalancerName: name,
+			Tags: []*elb.Tag{
+				{Key: aws.String(key.TagInstallation), Value: aws.String(testInstallation)},
+			},
+		})
+	}
+
+	return o, nil
+}
+
+type fakeELBv2 struct {
+	limits        [][]*elbv2.Limit
+	listeners     [][]*elbv2.Listener
+	loadBalancers [][]*elbv2.LoadBalancer
+	targetGroups  [][]*elbv2.TargetGroup
+}
+
+func (f *fakeELBv2) DescribeAccountLimitsWithContext(ctx aws.Context, input *elbv2.DescribeAccountLimitsInput, opts ...request.Option) (*elbv2.DescribeAccountLimitsOutput, error) {
+	page, next, err := fakePage(f.limits, input.Marker)
+	if err != nil {
+		return nil, err
+	}
+
+	return &elbv2.DescribeAccountLimitsOutput{Limits: page, NextMarker: next}, nil
+}
+
+func (f *fakeELBv2) DescribeListenersWithContext(ctx aws.Context, input *elbv2.DescribeListenersInput, opts ...request.Option) (*elbv2.DescribeListenersOutput, error) {
+	page, next, err := fakePage(f.listeners, input.Marker)
+	if err != nil {
+		return nil, err
+	}
+
+	return &elbv2.DescribeListenersOutput{Listeners: page, NextMarker: next}, nil
+}
+
+func (f *fakeELBv2) DescribeLoadBalancersWithContext(ctx aws.Context, input *elbv2.DescribeLoadBalancersInput, opts ...request.Option) (*elbv2.DescribeLoadBalancersOutput, error) {
+	page, next, err := fakePage(f.loadBalancers, input.Marker)
+	if err != nil {
+		return nil, err
+	}
+
+	return &elbv2.DescribeLoadBalancersOutput{LoadBalancers: page, NextMarker: next}, nil
+}
+
+func (f *fakeELBv2) DescribeTagsWithContext(ctx aws.Context, input *elbv2.DescribeTagsInput, opts ...request.Option) (*elbv2.DescribeTagsOutput, error) {
+	o := &elbv2.DescribeTagsOutput{}
+	for _, arn := range input.ResourceArns {
+		o.TagDescriptions = append(o.TagDescriptions, &elbv2.TagDescription{
+			ResourceArn: arn,
+			Tags: []*elbv2.Tag{
+				{Key: aws.String(key.TagInstallation), Value: aws.String(testInstallation)},
+			},
+		})
+	}
+
+	return o, nil
+}
+
+func (f *fakeELBv2) DescribeTargetGroupsWithContext(ctx aws.Context, input *elbv2.DescribeTargetGroupsInput, opts ...request.Option) (*elbv2.DescribeTargetGroupsOutput, error) {
+	page, next, err := fakePage(f.targetGroups, input.Marker)
+	if err != nil {
+		return nil, err
+	}
+
+	return &elbv2.DescribeTargetGroupsOutput{TargetGroups: page, NextMarker: next}, nil
+}
+
+type fakeServiceQuotas struct{}
+
+func (f *fakeServiceQuotas) ListAWSDefaultServiceQuotasWithContext(ctx aws.Context, input *servicequotas.ListAWSDefaultServiceQuotasInput, opts ...request.Option) (*servicequotas.ListAWSDefaultServiceQuotasOutput, error) {
+	return &servicequotas.ListAWSDefaultServiceQuotasOutput{
+		Quotas: []*servicequotas.ServiceQuota{{QuotaCode: aws.String(vcpuQuotaCodes[vcpuBucketStandard]), Value: aws.Float64(1)}},
+	}, nil
+}
+
+func (f *fakeServiceQuotas) ListServiceQuotasWithContext(ctx aws.Context, input *servicequotas.ListServiceQuotasInput, opts ...request.Option) (*servicequotas.ListServiceQuotasOutput, error) {
+	return &servicequotas.ListServiceQuotasOutput{}, nil
+}
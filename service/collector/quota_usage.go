@@ -0,0 +1,293 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	labelQuota = "quota"
+)
+
+const (
+	subsystemQuota = "quota"
+)
+
+const (
+	quotaCloudFormationStacks  = "cloudformation_stacks"
+	quotaElasticIPs            = "elastic_ips"
+	quotaNATGatewaysPerAZ      = "nat_gateways_per_availability_zone"
+	quotaOnDemandStandardVCPUs = "on_demand_standard_vcpus"
+	quotaVPCsPerRegion         = "vpcs_per_region"
+)
+
+var (
+	quotaUsageRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemQuota, "usage_ratio"),
+		"Gauge about the usage of a service quota as a ratio of its applied value.",
+		[]string{
+			labelQuota,
+			labelAccountID,
+			labelRegion,
+		},
+		nil,
+	)
+)
+
+// quotaUsages are the service quotas whose usage is computed by the collector
+// itself, so that they can be alerted on without joining limit and usage
+// metrics.
+var quotaUsages = []quotaUsage{
+	{
+		Name:        quotaCloudFormationStacks,
+		ServiceCode: "cloudformation",
+		QuotaCode:   "L-0485CFC4",
+		Usage:       cloudFormationStacksUsage,
+	},
+	{
+		Name:        quotaElasticIPs,
+		ServiceCode: ec2ServiceCode,
+		QuotaCode:   eipQuotaCode,
+		Usage:       elasticIPsUsage,
+	},
+	{
+		Name:        quotaNATGatewaysPerAZ,
+		ServiceCode: VPCServiceCode,
+		QuotaCode:   NATQuotaCode,
+		Usage:       natGatewaysPerAZUsage,
+	},
+	{
+		Name:        quotaOnDemandStandardVCPUs,
+		ServiceCode: ec2ServiceCode,
+		QuotaCode:   vcpuQuotaCodes[vcpuBucketStandard],
+		Usage:       onDemandStandardVCPUsUsage,
+	},
+	{
+		Name:        quotaVPCsPerRegion,
+		ServiceCode: VPCServiceCode,
+		QuotaCode:   "L-F678F1CE",
+		Usage:       vpcsUsage,
+	},
+}
+
+var (
+	// standardInstanceFamilies are the instance families counting against
+	// the "Running On-Demand Standard (A, C, D, H, I, M, R, T, Z) instances"
+	// quota.
+	standardInstanceFamilies = map[string]bool{
+		"a":  true,
+		"c":  true,
+		"d":  true,
+		"h":  true,
+		"i":  true,
+		"im": true,
+		"is": true,
+		"m":  true,
+		"r":  true,
+		"t":  true,
+		"z":  true,
+	}
+)
+
+type quotaUsage struct {
+	// Name is the value of the quota label.
+	Name        string
+	ServiceCode string
+	QuotaCode   string
+	// Usage returns the amount of the quota used in the given account and
+	// region. The helper gives access to the caches shared with
+	// other collectors.
+	Usage func(ctx context.Context, h *helper, acc account) (float64, error)
+}
+
+func (v *ServiceQuota) collectUsageForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+	for _, u := range quotaUsages {
+		quotas, err := v.helper.ServiceQuotas(ctx, acc, u.ServiceCode)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		q, ok := quotas[u.QuotaCode]
+		if !ok || q.Applied == 0 {
+			continue
+		}
+
+		cacheKey := acc.ID + "/" + acc.Region + "/" + u.Name
+
+		usage, ok := v.usageCache.Get(cacheKey)
+		if !ok {
+			usage, err = u.Usage(ctx, v.helper, acc)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			v.usageCache.Set(cacheKey, usage)
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			quotaUsageRatioDesc,
+			prometheus.GaugeValue,
+			usage/q.Applied,
+			u.Name,
+			acc.ID,
+			acc.Region,
+		)
+	}
+
+	return nil
+}
+
+func cloudFormationStacksUsage(ctx context.Context, h *helper, acc account) (float64, error) {
+	var summaries []*cloudformation.StackSummary
+	err := acc.Clients.CloudFormation.ListStacksPagesWithContext(ctx, &cloudformation.ListStacksInput{}, func(o *cloudformation.ListStacksOutput, lastPage bool) bool {
+		summaries = append(summaries, o.StackSummaries...)
+		return true
+	})
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	var count int
+	for _, s := range summaries {
+		// Deleted stacks are listed for 90 days but do not count against the
+		// quota.
+		if aws.StringValue(s.StackStatus) == cloudformation.StackStatusDeleteComplete {
+			continue
+		}
+		count++
+	}
+
+	return float64(count), nil
+}
+
+func elasticIPsUsage(ctx context.Context, h *helper, acc account) (float64, error) {
+	o, err := acc.Clients.EC2.DescribeAddressesWithContext(ctx, &ec2.DescribeAddressesInput{})
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return float64(len(o.Addresses)), nil
+}
+
+// natGatewaysPerAZUsage returns the number of NAT gateways in the availability
+// zone having the most of them, as the quota applies to every zone alone.
+func natGatewaysPerAZUsage(ctx context.Context, h *helper, acc account) (float64, error) {
+	var subnetIDs []*string
+	{
+		i := &ec2.DescribeNatGatewaysInput{
+			Filter: []*ec2.Filter{
+				{
+					Name:   aws.String("state"),
+					Values: aws.StringSlice([]string{ec2.NatGatewayStatePending, ec2.NatGatewayStateAvailable}),
+				},
+			},
+		}
+
+		var natGateways []*ec2.NatGateway
+		err := acc.Clients.EC2.DescribeNatGatewaysPagesWithContext(ctx, i, func(o *ec2.DescribeNatGatewaysOutput, lastPage bool) bool {
+			natGateways = append(natGateways, o.NatGateways...)
+			return true
+		})
+		if err != nil {
+			return 0, microerror.Mask(err)
+		}
+
+		for _, n := range natGateways {
+			subnetIDs = append(subnetIDs, n.SubnetId)
+		}
+	}
+
+	if len(subnetIDs) == 0 {
+		return 0, nil
+	}
+
+	zones := map[string]string{}
+	{
+		i := &ec2.DescribeSubnetsInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("subnet-id"),
+					Values: subnetIDs,
+				},
+			},
+		}
+
+		var subnets []*ec2.Subnet
+		err := acc.Clients.EC2.DescribeSubnetsPagesWithContext(ctx, i, func(o *ec2.DescribeSubnetsOutput, lastPage bool) bool {
+			subnets = append(subnets, o.Subnets...)
+			return true
+		})
+		if err != nil {
+			return 0, microerror.Mask(err)
+		}
+
+		for _, s := range subnets {
+			zones[aws.StringValue(s.SubnetId)] = aws.StringValue(s.AvailabilityZoneId)
+		}
+	}
+
+	return float64(maxPerZone(aws.StringValueSlice(subnetIDs), zones)), nil
+}
+
+// onDemandStandardVCPUsUsage returns the vCPUs of the running on-demand
+// instances of standard instance families, as found by the ec2instances
+// collector.
+func onDemandStandardVCPUsUsage(ctx context.Context, h *helper, acc account) (float64, error) {
+	running, err := h.RunningVCPUs(ctx, acc)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return float64(running[vcpuBucketStandard]), nil
+}
+
+func vpcsUsage(ctx context.Context, h *helper, acc account) (float64, error) {
+	var vpcs []*ec2.Vpc
+	err := acc.Clients.EC2.DescribeVpcsPagesWithContext(ctx, &ec2.DescribeVpcsInput{}, func(o *ec2.DescribeVpcsOutput, lastPage bool) bool {
+		vpcs = append(vpcs, o.Vpcs...)
+		return true
+	})
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	return float64(len(vpcs)), nil
+}
+
+// instanceFamily returns the family of the given instance type, e.g. "m" for
+// "m5.xlarge" or "im" for "im4gn.large".
+func instanceFamily(instanceType string) string {
+	i := strings.IndexFunc(instanceType, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if i < 0 {
+		return instanceType
+	}
+
+	return instanceType[:i]
+}
+
+// maxPerZone returns the highest number of the given subnets located in the
+// same availability zone.
+func maxPerZone(subnetIDs []string, zones map[string]string) int {
+	counts := map[string]int{}
+
+	var max int
+	for _, id := range subnetIDs {
+		z := zones[id]
+		counts[z]++
+
+		if counts[z] > max {
+			max = counts[z]
+		}
+	}
+
+	return max
+}
@@ -0,0 +1,54 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestMaxPerZone(t *testing.T) {
+	testCases := []struct {
+		name      string
+		subnetIDs []string
+		zones     map[string]string
+
+		expectedMax int
+	}{
+		{
+			name: "case 0: no subnets",
+
+			expectedMax: 0,
+		},
+		{
+			name:      "case 1: subnets in different zones",
+			subnetIDs: []string{"subnet-1", "subnet-2", "subnet-3"},
+			zones: map[string]string{
+				"subnet-1": "euc1-az1",
+				"subnet-2": "euc1-az2",
+				"subnet-3": "euc1-az3",
+			},
+
+			expectedMax: 1,
+		},
+		{
+			name:      "case 2: several NAT gateways in the same subnet and zone",
+			subnetIDs: []string{"subnet-1", "subnet-1", "subnet-2", "subnet-3"},
+			zones: map[string]string{
+				"subnet-1": "euc1-az1",
+				"subnet-2": "euc1-az1",
+				"subnet-3": "euc1-az3",
+			},
+
+			expectedMax: 3,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			max := maxPerZone(tc.subnetIDs, tc.zones)
+
+			if max != tc.expectedMax {
+				t.Fatalf("expected %d, got %d", tc.expectedMax, max)
+			}
+		})
+	}
+}
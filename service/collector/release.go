@@ -0,0 +1,230 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	infrastructurev1alpha3 "github.com/giantswarm/apiextensions/v6/pkg/apis/infrastructure/v1alpha3"
+	"github.com/giantswarm/k8smetadata/pkg/label"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	releasev1alpha1 "github.com/giantswarm/release-operator/v4/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	labelClusterNamespace = "cluster_namespace"
+	labelComponent        = "component"
+	labelReleaseVersion   = "release_version"
+	labelComponentVersion = "version"
+)
+
+const (
+	// subsystemRelease will become the second part of the metric name, right
+	// after namespace.
+	subsystemRelease = "release"
+)
+
+var (
+	// releaseNamePrefixes are the prefixes of Release CR names in front of
+	// the release version, which is what clusters are labelled with. Vintage
+	// releases are prefixed with "v", Cluster API releases with the provider.
+	releaseNamePrefixes = []string{"v", "aws-"}
+)
+
+var (
+	releaseClusterDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRelease, "cluster_info"),
+		"Gauge about the release version of a tenant cluster. Always 1.",
+		[]string{
+			labelCluster,
+			labelClusterNamespace,
+			labelReleaseVersion,
+		},
+		nil,
+	)
+	releaseComponentDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRelease, "component_info"),
+		"Gauge about the version of a component of a release. Always 1.",
+		[]string{
+			labelReleaseVersion,
+			labelComponent,
+			labelComponentVersion,
+		},
+		nil,
+	)
+	releaseDeprecatedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRelease, "deprecated"),
+		"Gauge indicating whether a release is deprecated. 1 = deprecated, 0 = not deprecated",
+		[]string{
+			labelReleaseVersion,
+		},
+		nil,
+	)
+)
+
+type ReleaseConfig struct {
+	Helper *helper
+	Logger micrologger.Logger
+}
+
+// Release collects the release versions of tenant clusters and the
+// components of the AWS releases, so that AWS metrics can be correlated with
+// release versions.
+type Release struct {
+	helper *helper
+	logger micrologger.Logger
+}
+
+func NewRelease(config ReleaseConfig) (*Release, error) {
+	if config.Helper == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Helper must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	r := &Release{
+		helper: config.Helper,
+		logger: config.Logger,
+	}
+
+	return r, nil
+}
+
+func (r *Release) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	clusterReleases, err := r.helper.ClusterReleaseVersions(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for cluster, version := range clusterReleases {
+		ch <- prometheus.MustNewConstMetric(
+			releaseClusterDesc,
+			prometheus.GaugeValue,
+			GaugeValue,
+			cluster.Name,
+			cluster.Namespace,
+			version,
+		)
+	}
+
+	releases, err := r.helper.Releases(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, release := range releases {
+		version, ok := releaseVersion(release.GetName())
+		if !ok {
+			continue
+		}
+
+		for _, c := range release.Spec.Components {
+			ch <- prometheus.MustNewConstMetric(
+				releaseComponentDesc,
+				prometheus.GaugeValue,
+				GaugeValue,
+				version,
+				c.Name,
+				c.Version,
+			)
+		}
+
+		var deprecated float64
+		if release.Spec.State == releasev1alpha1.StateDeprecated {
+			deprecated = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			releaseDeprecatedDesc,
+			prometheus.GaugeValue,
+			deprecated,
+			version,
+		)
+	}
+
+	return nil
+}
+
+func (r *Release) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- releaseClusterDesc
+	ch <- releaseComponentDesc
+	ch <- releaseDeprecatedDesc
+	return nil
+}
+
+// ClusterReleaseVersions returns the release version of every tenant cluster
+// keyed by namespace and name of its AWSCluster CR, based on the release
+// version label of the Giant Swarm and Cluster API Provider AWS AWSCluster
+// CRs. Clusters without the label are not part of the result.
+func (h *helper) ClusterReleaseVersions(ctx context.Context) (map[types.NamespacedName]string, error) {
+	versions := map[types.NamespacedName]string{}
+
+	{
+		list := &infrastructurev1alpha3.AWSClusterList{}
+
+		// Installations purely based on Cluster API do not have the CRD.
+		err := h.clients.CtrlClient().List(ctx, list)
+		if err != nil && !meta.IsNoMatchError(err) {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, cr := range list.Items {
+			if v := cr.GetLabels()[label.ReleaseVersion]; v != "" {
+				versions[types.NamespacedName{Namespace: cr.GetNamespace(), Name: cr.GetName()}] = v
+			}
+		}
+	}
+
+	{
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(capaClusterListGVK)
+
+		// Vintage installations do not have the CRD.
+		err := h.clients.CtrlClient().List(ctx, list)
+		if err != nil && !meta.IsNoMatchError(err) {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, cr := range list.Items {
+			if v := cr.GetLabels()[label.ReleaseVersion]; v != "" {
+				versions[types.NamespacedName{Namespace: cr.GetNamespace(), Name: cr.GetName()}] = v
+			}
+		}
+	}
+
+	return versions, nil
+}
+
+// Releases returns all Release CRs. Installations without the CRD have no
+// releases.
+func (h *helper) Releases(ctx context.Context) ([]releasev1alpha1.Release, error) {
+	list := &releasev1alpha1.ReleaseList{}
+
+	err := h.clients.CtrlClient().List(ctx, list)
+	if meta.IsNoMatchError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return list.Items, nil
+}
+
+// releaseVersion returns the release version of the Release CR with the given
+// name. The boolean is false for releases of other providers.
+func releaseVersion(name string) (string, bool) {
+	for _, prefix := range releaseNamePrefixes {
+		v := strings.TrimPrefix(name, prefix)
+		if v != name && v != "" && unicode.IsDigit(rune(v[0])) {
+			return v, true
+		}
+	}
+
+	return "", false
+}
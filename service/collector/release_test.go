@@ -0,0 +1,52 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestReleaseVersion(t *testing.T) {
+	testCases := []struct {
+		name        string
+		releaseName string
+
+		expectedVersion string
+		expectedOK      bool
+	}{
+		{
+			name:        "case 0: vintage release",
+			releaseName: "v19.3.0",
+
+			expectedVersion: "19.3.0",
+			expectedOK:      true,
+		},
+		{
+			name:        "case 1: Cluster API release",
+			releaseName: "aws-25.0.0",
+
+			expectedVersion: "25.0.0",
+			expectedOK:      true,
+		},
+		{
+			name:        "case 2: release of another provider",
+			releaseName: "vsphere-25.0.0",
+		},
+		{
+			name:        "case 3: release of another provider",
+			releaseName: "azure-25.0.0",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			version, ok := releaseVersion(tc.releaseName)
+
+			if ok != tc.expectedOK {
+				t.Fatalf("expected %t, got %t", tc.expectedOK, ok)
+			}
+			if version != tc.expectedVersion {
+				t.Fatalf("expected %#q, got %#q", tc.expectedVersion, version)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	clientaws "github.com/giantswarm/aws-collector/client/aws"
+)
+
+const (
+	labelResult = "result"
+)
+
+const (
+	resultFailure = "failure"
+	resultSuccess = "success"
+)
+
+var (
+	// durationBuckets are chosen to cover collections of a few seconds up to
+	// collections exceeding common scrape timeouts by far.
+	durationBuckets = []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+
+	collectorDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystemCollector,
+		Name:      "duration_seconds",
+		Help:      "Histogram for the duration of collections of a collector.",
+		Buckets:   durationBuckets,
+	}, []string{
+		labelCollector,
+	})
+	collectorAccountDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystemCollector,
+		Name:      "account_duration_seconds",
+		Help:      "Histogram for the duration of collections of a collector for a single AWS account and region.",
+		Buckets:   durationBuckets,
+	}, []string{
+		labelCollector,
+		labelAccountID,
+		labelRegion,
+	})
+	collectorRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystemCollector,
+		Name:      "runs_total",
+		Help:      "Number of collections of a collector by result.",
+	}, []string{
+		labelCollector,
+		labelResult,
+	})
+	collectorSeries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystemCollector,
+		Name:      "series_count",
+		Help:      "Gauge about the number of series emitted by the last successful collection of a collector.",
+	}, []string{
+		labelCollector,
+	})
+)
+
+// selfMetrics exposes metrics about the behaviour of the collectors
+// themselves and the AWS API calls they make.
+type selfMetrics struct{}
+
+// Collect is the main metrics collection function.
+func (s *selfMetrics) Collect(ch chan<- prometheus.Metric) error {
+	collectorDuration.Collect(ch)
+	collectorAccountDuration.Collect(ch)
+	collectorRuns.Collect(ch)
+	collectorSeries.Collect(ch)
+
+	getChecksDuration.Collect(ch)
+	getResourcesDuration.Collect(ch)
+
+	clientaws.CollectMetrics(ch)
+
+	return nil
+}
+
+// Describe emits the description for the metrics collected here.
+func (s *selfMetrics) Describe(ch chan<- *prometheus.Desc) error {
+	collectorDuration.Describe(ch)
+	collectorAccountDuration.Describe(ch)
+	collectorRuns.Describe(ch)
+	collectorSeries.Describe(ch)
+
+	getChecksDuration.Describe(ch)
+	getResourcesDuration.Describe(ch)
+
+	clientaws.DescribeMetrics(ch)
+
+	return nil
+}
@@ -2,54 +2,130 @@ package collector
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/servicequotas"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
-	"golang.org/x/sync/errgroup"
 
 	clientaws "github.com/giantswarm/aws-collector/client/aws"
 	"github.com/giantswarm/aws-collector/service/internal/cache"
 )
 
 const (
+	labelAdjustable   = "adjustable"
+	labelQuotaCode    = "quota_code"
+	labelQuotaName    = "quota_name"
+	labelServiceCode  = "service_code"
 	labelServiceQuota = "service_quota"
+	labelValueType    = "value_type"
 )
 
 const (
 	subsystemServiceQuota = "servicequota"
 )
 
+const (
+	valueTypeApplied = "applied"
+	valueTypeDefault = "default"
+)
+
 var (
 	serviceQuotaDesc *prometheus.Desc = prometheus.NewDesc(
 		prometheus.BuildFQName(namespace, subsystemServiceQuota, "info"),
 		"Service Quota information.",
 		[]string{
 			labelAccountID,
+			labelRegion,
 			labelServiceQuota,
 		},
 		nil,
 	)
+	serviceQuotaLimitDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemServiceQuota, "limit"),
+		"Gauge about the value of a service quota. The applied value is the one effective in the account and region.",
+		[]string{
+			labelAccountID,
+			labelRegion,
+			labelAdjustable,
+			labelQuotaCode,
+			labelQuotaName,
+			labelServiceCode,
+			labelValueType,
+		},
+		nil,
+	)
 	NATQuotaCode   = "L-FE5A380F"
 	NATQuotaName   = "nat-gateway"
 	VPCServiceCode = "vpc"
 )
 
+// DefaultServiceQuotas are the service quotas collected unless configured
+// otherwise, given as service code and quota code separated by a slash.
+var DefaultServiceQuotas = []string{
+	// VPCs per Region.
+	"vpc/L-F678F1CE",
+	// NAT gateways per Availability Zone.
+	"vpc/L-FE5A380F",
+	// Network interfaces per Region.
+	"vpc/L-DF5E4CA3",
+	// VPC security groups per Region.
+	"vpc/L-E79EC296",
+	// EC2-VPC Elastic IPs.
+	"ec2/L-0263D0A3",
+	// Running On-Demand Standard (A, C, D, H, I, M, R, T, Z) instances, in
+	// vCPUs.
+	"ec2/L-1216C47A",
+	// Classic Load Balancers per Region.
+	"elasticloadbalancing/L-E9E9831D",
+	// Application Load Balancers per Region.
+	"elasticloadbalancing/L-53DA6B97",
+	// Network Load Balancers per Region.
+	"elasticloadbalancing/L-69A177A2",
+	// Stack count.
+	"cloudformation/L-0485CFC4",
+}
+
 type ServiceQuotaConfig struct {
 	Helper *helper
 	Logger micrologger.Logger
 
 	InstallationName string
+	// Quotas are the service quotas to collect, given as service code and
+	// quota code separated by a slash, e.g. "vpc/L-F678F1CE". Defaults to
+	// DefaultServiceQuotas.
+	Quotas []string
 }
 
+// ServiceQuota collects the values of the configured service quotas and the
+// usage of key quotas. In contrast to Trusted Advisor, the Service Quotas API
+// does not require a Business support plan.
 type ServiceQuota struct {
-	awsAPIcache *cache.Float64Cache
-	helper      *helper
-	logger      micrologger.Logger
+	helper     *helper
+	logger     micrologger.Logger
+	usageCache *cache.Float64Cache
 
 	installationName string
+	quotas           []serviceQuotaID
+}
+
+type serviceQuotaID struct {
+	ServiceCode string
+	QuotaCode   string
+}
+
+type serviceQuota struct {
+	Adjustable bool
+	// Applied is the value effective in the account and region. It equals
+	// Default unless the quota was increased.
+	Applied float64
+	Default float64
+	Name    string
 }
 
 func NewServiceQuota(config ServiceQuotaConfig) (*ServiceQuota, error) {
@@ -63,48 +139,39 @@ func NewServiceQuota(config ServiceQuotaConfig) (*ServiceQuota, error) {
 	if config.InstallationName == "" {
 		return nil, microerror.Maskf(invalidConfigError, "%T.InstallationName must not be empty", config)
 	}
+	if len(config.Quotas) == 0 {
+		config.Quotas = DefaultServiceQuotas
+	}
+
+	quotas, err := parseServiceQuotaIDs(config.Quotas)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
 
 	v := &ServiceQuota{
-		// Default quotas are changed by request to AWS support and they are
-		// considered quite static information, then 12 hours for the cache
-		// expiration is a reasonable value.
-		awsAPIcache: cache.NewFloat64Cache(time.Minute * 720),
-		helper:      config.Helper,
-		logger:      config.Logger,
+		helper: config.Helper,
+		logger: config.Logger,
+		// Computing the usages lists whole resource types of every account,
+		// so they are only refreshed every few minutes, which is still
+		// accurate enough compared to the 12 hours the quotas are cached.
+		usageCache: cache.NewFloat64Cache(10 * time.Minute),
 
 		installationName: config.InstallationName,
+		quotas:           quotas,
 	}
 
 	return v, nil
 }
 
-func (v *ServiceQuota) Collect(ch chan<- prometheus.Metric) error {
-	reconciledClusters, err := v.helper.ListReconciledClusters()
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	awsClientsList, err := v.helper.GetAWSClients(context.Background(), reconciledClusters)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	var g errgroup.Group
-
-	for _, item := range awsClientsList {
-		awsClients := item
-
-		g.Go(func() error {
-			err := v.collectForAccount(ch, awsClients)
-			if err != nil {
-				return microerror.Mask(err)
-			}
-
-			return nil
-		})
-	}
+func (v *ServiceQuota) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	err := v.helper.ForEachAccount(ctx, collectorServiceQuota, func(acc account) error {
+		err := v.collectForAccount(ctx, ch, acc)
+		if err != nil {
+			return microerror.Mask(err)
+		}
 
-	err = g.Wait()
+		return nil
+	})
 	if err != nil {
 		return microerror.Mask(err)
 	}
@@ -114,67 +181,175 @@ func (v *ServiceQuota) Collect(ch chan<- prometheus.Metric) error {
 
 func (v *ServiceQuota) Describe(ch chan<- *prometheus.Desc) error {
 	ch <- serviceQuotaDesc
+	ch <- serviceQuotaLimitDesc
+	ch <- quotaUsageRatioDesc
 	return nil
 }
 
-func (v *ServiceQuota) collectForAccount(ch chan<- prometheus.Metric, awsClients clientaws.Clients) error {
-	accountID, err := v.helper.AWSAccountID(awsClients)
-	if err != nil {
-		return microerror.Mask(err)
+func (v *ServiceQuota) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+	for _, id := range v.quotas {
+		quotas, err := v.helper.ServiceQuotas(ctx, acc, id.ServiceCode)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		q, ok := quotas[id.QuotaCode]
+		if !ok {
+			v.logger.Log("level", "debug", "message", fmt.Sprintf("service quota %#q of service %#q not found in account %s and region %s", id.QuotaCode, id.ServiceCode, acc.ID, acc.Region))
+			continue
+		}
+
+		values := map[string]float64{
+			valueTypeApplied: q.Applied,
+			valueTypeDefault: q.Default,
+		}
+		for valueType, value := range values {
+			ch <- prometheus.MustNewConstMetric(
+				serviceQuotaLimitDesc,
+				prometheus.GaugeValue,
+				value,
+				acc.ID,
+				acc.Region,
+				strconv.FormatBool(q.Adjustable),
+				id.QuotaCode,
+				q.Name,
+				id.ServiceCode,
+				valueType,
+			)
+		}
 	}
 
 	// natQuotaValue reflects the value of number of NAT Gateways that can be
 	// created by the operator in a specific VPC for each availability zone.
+	// It is always emitted for backward compatibility.
 	var natQuotaValue float64
-	if val, ok := v.awsAPIcache.Get(NATQuotaCode); ok {
-		natQuotaValue = val
-	} else {
-		natQuotaValue, err := getDefaultVPCQuotaFor(NATQuotaCode, awsClients)
+	{
+		quotas, err := v.helper.ServiceQuotas(ctx, acc, VPCServiceCode)
 		if err != nil {
 			return microerror.Mask(err)
 		}
-		v.awsAPIcache.Set(NATQuotaCode, natQuotaValue)
+
+		natQuotaValue = quotas[NATQuotaCode].Applied
 	}
 
 	ch <- prometheus.MustNewConstMetric(
 		serviceQuotaDesc,
 		prometheus.GaugeValue,
 		natQuotaValue,
-		accountID,
+		acc.ID,
+		acc.Region,
 		NATQuotaName,
 	)
 
+	err := v.collectUsageForAccount(ctx, ch, acc)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
 	return nil
 }
 
-func getDefaultVPCQuotaFor(quotaCode string, awsClients clientaws.Clients) (float64, error) {
-	id := &servicequotas.GetAWSDefaultServiceQuotaInput{
-		QuotaCode:   &NATQuotaCode,
-		ServiceCode: &VPCServiceCode,
+// ServiceQuotas returns the quotas of the given service in the account and
+// region keyed by quota code. The result is shared by all collectors and
+// cached per account, region and service, including quotas not found and
+// regions not supporting the Service Quotas API, which have no quotas.
+func (h *helper) ServiceQuotas(ctx context.Context, acc account, serviceCode string) (map[string]serviceQuota, error) {
+	cacheKey := acc.ID + "/" + acc.Region + "/" + serviceCode
+
+	if quotas, ok := h.quotaCache.Get(cacheKey); ok {
+		return quotas, nil
 	}
-	//Get the default NAT quota for the specific account
-	od, err := awsClients.ServiceQuotas.GetAWSDefaultServiceQuota(id)
+
+	quotas, err := listServiceQuotas(ctx, acc.Clients, serviceCode)
 	if IsEndpointNotAvailable(err) {
 		// Some regions do not support ServiceQuota API.
-		return 0, nil
+		quotas = map[string]serviceQuota{}
 	} else if err != nil {
-		return 0, microerror.Mask(err)
+		return nil, microerror.Mask(err)
+	}
+
+	h.quotaCache.Set(cacheKey, quotas)
+
+	return quotas, nil
+}
+
+// listServiceQuotas returns the default values of all quotas of the given
+// service, overridden by the values applied to the account.
+func listServiceQuotas(ctx context.Context, awsClients clientaws.Clients, serviceCode string) (map[string]serviceQuota, error) {
+	quotas := map[string]serviceQuota{}
+
+	{
+		i := &servicequotas.ListAWSDefaultServiceQuotasInput{
+			ServiceCode: aws.String(serviceCode),
+		}
+
+		var list []*servicequotas.ServiceQuota
+		err := awsClients.ServiceQuotas.ListAWSDefaultServiceQuotasPagesWithContext(ctx, i, func(o *servicequotas.ListAWSDefaultServiceQuotasOutput, lastPage bool) bool {
+			list = append(list, o.Quotas...)
+			return true
+		})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		mergeServiceQuotas(quotas, list, false)
 	}
-	natQuotaValue := *od.Quota.Value
 
-	il := &servicequotas.ListServiceQuotasInput{
-		ServiceCode: &VPCServiceCode,
+	{
+		i := &servicequotas.ListServiceQuotasInput{
+			ServiceCode: aws.String(serviceCode),
+		}
+
+		var list []*servicequotas.ServiceQuota
+		err := awsClients.ServiceQuotas.ListServiceQuotasPagesWithContext(ctx, i, func(o *servicequotas.ListServiceQuotasOutput, lastPage bool) bool {
+			list = append(list, o.Quotas...)
+			return true
+		})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		mergeServiceQuotas(quotas, list, true)
 	}
-	//Get the NAT quota in case it has been modified by AWS support request
-	ol, err := awsClients.ServiceQuotas.ListServiceQuotas(il)
-	if err != nil {
-		return natQuotaValue, microerror.Mask(err)
+
+	return quotas, nil
+}
+
+// mergeServiceQuotas merges the given quotas into the map keyed by quota code.
+// Default values are also used as applied values until the applied ones are
+// merged.
+func mergeServiceQuotas(quotas map[string]serviceQuota, list []*servicequotas.ServiceQuota, applied bool) {
+	for _, sq := range list {
+		if sq.QuotaCode == nil || sq.Value == nil {
+			continue
+		}
+
+		code := *sq.QuotaCode
+		q, ok := quotas[code]
+
+		q.Adjustable = aws.BoolValue(sq.Adjustable)
+		q.Applied = *sq.Value
+		q.Name = aws.StringValue(sq.QuotaName)
+		if !applied || !ok {
+			q.Default = *sq.Value
+		}
+
+		quotas[code] = q
 	}
-	for _, sq := range ol.Quotas {
-		if *sq.QuotaCode == NATQuotaCode {
-			natQuotaValue = *sq.Value
+}
+
+// parseServiceQuotaIDs parses quotas given as service code and quota code
+// separated by a slash.
+func parseServiceQuotaIDs(quotas []string) ([]serviceQuotaID, error) {
+	var ids []serviceQuotaID
+	for _, q := range quotas {
+		serviceCode, quotaCode, ok := strings.Cut(q, "/")
+		if !ok || serviceCode == "" || quotaCode == "" {
+			return nil, microerror.Maskf(invalidConfigError, "service quota %#q must be given as <service code>/<quota code>", q)
 		}
+
+		ids = append(ids, serviceQuotaID{ServiceCode: serviceCode, QuotaCode: quotaCode})
 	}
 
-	return natQuotaValue, nil
+	return ids, nil
 }
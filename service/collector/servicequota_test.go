@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+)
+
+func TestParseServiceQuotaIDs(t *testing.T) {
+	testCases := []struct {
+		name   string
+		quotas []string
+
+		expectedIDs          []serviceQuotaID
+		expectedErrorMatcher func(error) bool
+	}{
+		{
+			name:   "case 0: valid quotas",
+			quotas: []string{"vpc/L-F678F1CE", "ec2/L-1216C47A"},
+
+			expectedIDs: []serviceQuotaID{
+				{ServiceCode: "vpc", QuotaCode: "L-F678F1CE"},
+				{ServiceCode: "ec2", QuotaCode: "L-1216C47A"},
+			},
+		},
+		{
+			name:   "case 1: missing service code",
+			quotas: []string{"L-F678F1CE"},
+
+			expectedErrorMatcher: IsInvalidConfig,
+		},
+		{
+			name:   "case 2: empty quota code",
+			quotas: []string{"vpc/"},
+
+			expectedErrorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			ids, err := parseServiceQuotaIDs(tc.quotas)
+
+			switch {
+			case err == nil && tc.expectedErrorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.expectedErrorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.expectedErrorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.expectedErrorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if !reflect.DeepEqual(ids, tc.expectedIDs) {
+				t.Fatalf("expected %#v, got %#v", tc.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+func TestMergeServiceQuotas(t *testing.T) {
+	defaults := []*servicequotas.ServiceQuota{
+		{
+			Adjustable: aws.Bool(true),
+			QuotaCode:  aws.String("L-F678F1CE"),
+			QuotaName:  aws.String("VPCs per Region"),
+			Value:      aws.Float64(5),
+		},
+		{
+			Adjustable: aws.Bool(true),
+			QuotaCode:  aws.String("L-FE5A380F"),
+			QuotaName:  aws.String("NAT gateways per Availability Zone"),
+			Value:      aws.Float64(5),
+		},
+		{QuotaCode: aws.String("L-00000000")},
+	}
+	applied := []*servicequotas.ServiceQuota{
+		{
+			Adjustable: aws.Bool(true),
+			QuotaCode:  aws.String("L-F678F1CE"),
+			QuotaName:  aws.String("VPCs per Region"),
+			Value:      aws.Float64(20),
+		},
+	}
+
+	quotas := map[string]serviceQuota{}
+	mergeServiceQuotas(quotas, defaults, false)
+	mergeServiceQuotas(quotas, applied, true)
+
+	expected := map[string]serviceQuota{
+		"L-F678F1CE": {Adjustable: true, Applied: 20, Default: 5, Name: "VPCs per Region"},
+		"L-FE5A380F": {Adjustable: true, Applied: 5, Default: 5, Name: "NAT gateways per Availability Zone"},
+	}
+
+	if !reflect.DeepEqual(quotas, expected) {
+		t.Fatalf("expected %#v, got %#v", expected, quotas)
+	}
+}
@@ -1,6 +1,11 @@
 package collector
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/giantswarm/exporterkit/collector"
 	"github.com/giantswarm/k8sclient/v7/pkg/k8sclient"
 	"github.com/giantswarm/microerror"
@@ -13,32 +18,143 @@ type SetConfig struct {
 	Clients k8sclient.Interface
 	Logger  micrologger.Logger
 
-	AWSConfig             clientaws.Config
-	InstallationName      string
-	TrustedAdvisorEnabled bool
+	AWSConfig clientaws.Config
+	// Background defines whether collectors run in the background on their
+	// own interval, with Prometheus scrapes being served from the last
+	// snapshot.
+	Background bool
+	// CAPADiscovery enables the discovery of Cluster API Provider AWS
+	// clusters.
+	CAPADiscovery bool
+	// Collectors holds the configuration of single collectors keyed by
+	// collector name. Collectors not configured here use their defaults.
+	Collectors map[string]CollectorConfig
+	// DeletedStacksGracePeriod is the period for which the cloudformation
+	// collector reports deleted stacks. See
+	// CloudFormationConfig.DeletedStacksGracePeriod.
+	DeletedStacksGracePeriod time.Duration
+	// DriftDetection enables the drift detection of the cloudformation
+	// collector. See CloudFormationConfig.DriftDetection.
+	DriftDetection         bool
+	DriftDetectionInterval time.Duration
+	// GiantSwarmDiscovery enables the discovery of clusters based on the
+	// infrastructure.giantswarm.io CRs.
+	GiantSwarmDiscovery bool
+	InstallationName    string
+	Interval            time.Duration
+	// NATGatewayCloudWatchMetrics enables the CloudWatch metrics of the
+	// natgateway collector. See NATGatewayConfig.CloudWatchMetrics.
+	NATGatewayCloudWatchMetrics bool
+	// ServiceQuotas are the service quotas collected by the servicequota
+	// collector. See ServiceQuotaConfig.Quotas.
+	ServiceQuotas []string
+}
+
+// CollectorConfig is the configuration of a single collector.
+type CollectorConfig struct {
+	Enabled bool
+	// Interval overrides the interval in which the collector refreshes its
+	// metrics.
+	Interval time.Duration
+	// Timeout limits the duration of a single collection. Zero means no
+	// timeout.
+	Timeout time.Duration
 }
 
+var (
+	// disabledCollectors defines the collectors which are disabled unless
+	// explicitly enabled. Trusted Advisor requires a Business support plan.
+	disabledCollectors = map[string]bool{
+		collectorTrustedAdvisor: true,
+	}
+
+	// minCollectorIntervals defines the minimum refresh interval of collectors
+	// whose information is expensive to gather and changes only slowly.
+	minCollectorIntervals = map[string]time.Duration{
+		collectorELB:   5 * time.Minute,
+		collectorELBv2: 5 * time.Minute,
+		// AWS operator creates at this moment one NAT for each private subnet
+		// (node pool). As clusters are not created nor changed so often, and
+		// the process can take around 20 minutes, 30 minutes is a reasonable
+		// value.
+		collectorNAT:    30 * time.Minute,
+		collectorSubnet: 5 * time.Minute,
+	}
+)
+
 // Set is basically only a wrapper for the collector implementations.
 // It eases the initialization and prevents some weird import mess so we do not
 // have to alias packages. There is also the benefit of the helper type kept
 // private so we do not need to expose this magic.
 type Set struct {
 	*collector.Set
+
+	snapshots []*snapshot
+}
+
+type namedCollector struct {
+	name      string
+	collector contextCollector
 }
 
 func NewSet(config SetConfig) (*Set, error) {
-	var err error
+	if config.Clients == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Clients must not be empty", config)
+	}
 
-	var h *helper
-	{
+	var err error
 
-		c := helperConfig{
+	var discoveries []clusterDiscovery
+	if config.GiantSwarmDiscovery {
+		c := giantSwarmDiscoveryConfig{
 			Clients: config.Clients,
 			Logger:  config.Logger,
+		}
+
+		d, err := newGiantSwarmDiscovery(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		discoveries = append(discoveries, d)
+	}
+	if config.CAPADiscovery {
+		c := capaDiscoveryConfig{
+			Client: config.Clients.CtrlClient(),
+			Logger: config.Logger,
+		}
+
+		d, err := newCAPADiscovery(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		discoveries = append(discoveries, d)
+	}
+
+	var registry *accountRegistry
+	{
+		c := accountRegistryConfig{
+			Discoveries: discoveries,
+			Logger:      config.Logger,
 
 			AWSConfig: config.AWSConfig,
 		}
 
+		registry, err = newAccountRegistry(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var h *helper
+	{
+		c := helperConfig{
+			Clients:  config.Clients,
+			Logger:   config.Logger,
+			Registry: registry,
+		}
+
 		h, err = newHelper(c)
 		if err != nil {
 			return nil, microerror.Mask(err)
@@ -51,7 +167,10 @@ func NewSet(config SetConfig) (*Set, error) {
 			Helper: h,
 			Logger: config.Logger,
 
-			InstallationName: config.InstallationName,
+			DeletedStacksGracePeriod: config.DeletedStacksGracePeriod,
+			DriftDetection:           config.DriftDetection,
+			DriftDetectionInterval:   config.DriftDetectionInterval,
+			InstallationName:         config.InstallationName,
 		}
 
 		cfCollector, err = NewCloudFormation(c)
@@ -75,6 +194,21 @@ func NewSet(config SetConfig) (*Set, error) {
 		}
 	}
 
+	var ebsCollector *EBS
+	{
+		c := EBSConfig{
+			Helper: h,
+			Logger: config.Logger,
+
+			InstallationName: config.InstallationName,
+		}
+
+		ebsCollector, err = NewEBS(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var ec2InstancesCollector *EC2Instances
 	{
 		c := EC2InstancesConfig{
@@ -90,6 +224,19 @@ func NewSet(config SetConfig) (*Set, error) {
 		}
 	}
 
+	var eipCollector *EIP
+	{
+		c := EIPConfig{
+			Helper: h,
+			Logger: config.Logger,
+		}
+
+		eipCollector, err = NewEIP(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var elbCollector *ELB
 	{
 		c := ELBConfig{
@@ -105,6 +252,21 @@ func NewSet(config SetConfig) (*Set, error) {
 		}
 	}
 
+	var elbv2Collector *ELBv2
+	{
+		c := ELBv2Config{
+			Helper: h,
+			Logger: config.Logger,
+
+			InstallationName: config.InstallationName,
+		}
+
+		elbv2Collector, err = NewELBv2(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var sqCollector *ServiceQuota
 	{
 		c := ServiceQuotaConfig{
@@ -112,6 +274,7 @@ func NewSet(config SetConfig) (*Set, error) {
 			Logger: config.Logger,
 
 			InstallationName: config.InstallationName,
+			Quotas:           config.ServiceQuotas,
 		}
 
 		sqCollector, err = NewServiceQuota(c)
@@ -135,6 +298,22 @@ func NewSet(config SetConfig) (*Set, error) {
 		}
 	}
 
+	var natGatewayCollector *NATGateway
+	{
+		c := NATGatewayConfig{
+			Helper: h,
+			Logger: config.Logger,
+
+			CloudWatchMetrics: config.NATGatewayCloudWatchMetrics,
+			InstallationName:  config.InstallationName,
+		}
+
+		natGatewayCollector, err = NewNATGateway(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var trustedAdvisorCollector *TrustedAdvisor
 	{
 		c := TrustedAdvisorConfig{
@@ -163,6 +342,19 @@ func NewSet(config SetConfig) (*Set, error) {
 		}
 	}
 
+	var releaseCollector *Release
+	{
+		c := ReleaseConfig{
+			Helper: h,
+			Logger: config.Logger,
+		}
+
+		releaseCollector, err = NewRelease(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var updateCollector *Update
 	{
 		c := UpdateConfig{
@@ -191,26 +383,72 @@ func NewSet(config SetConfig) (*Set, error) {
 		}
 	}
 
+	named := []namedCollector{
+		{name: collectorCloudFormation, collector: cfCollector},
+		{name: collectorASG, collector: asgCollector},
+		{name: collectorEBS, collector: ebsCollector},
+		{name: collectorEC2Instances, collector: ec2InstancesCollector},
+		{name: collectorEIP, collector: eipCollector},
+		{name: collectorELB, collector: elbCollector},
+		{name: collectorELBv2, collector: elbv2Collector},
+		{name: collectorServiceQuota, collector: sqCollector},
+		{name: collectorNAT, collector: natCollector},
+		{name: collectorNATGateway, collector: natGatewayCollector},
+		{name: collectorRelease, collector: releaseCollector},
+		{name: collectorSubnet, collector: subnetCollector},
+		{name: collectorTrustedAdvisor, collector: trustedAdvisorCollector},
+		{name: collectorUpdate, collector: updateCollector},
+		{name: collectorVPC, collector: vpcCollector},
+	}
+
+	err = validateCollectorConfigs(named, config.Collectors)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var snapshots []*snapshot
+	for _, n := range named {
+		cc := collectorConfig(n.name, config.Collectors)
+		if !cc.Enabled {
+			config.Logger.Log("level", "debug", "message", fmt.Sprintf("%s collector is disabled", n.name))
+			continue
+		}
+
+		interval := cc.Interval
+		if interval == 0 {
+			interval = collectorInterval(n.name, config.Background, config.Interval)
+		}
+
+		c := snapshotConfig{
+			Collector: n.collector,
+			Logger:    config.Logger,
+
+			Background: config.Background,
+			Interval:   interval,
+			Name:       n.name,
+			Timeout:    cc.Timeout,
+		}
+
+		s, err := newSnapshot(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		snapshots = append(snapshots, s)
+	}
+
 	var collectorSet *collector.Set
 	{
 		c := collector.SetConfig{
 			Collectors: []collector.Interface{
-				cfCollector,
-				asgCollector,
-				ec2InstancesCollector,
-				elbCollector,
-				sqCollector,
-				natCollector,
-				subnetCollector,
-				updateCollector,
-				vpcCollector,
+				registry,
+				&selfMetrics{},
 			},
 			Logger: config.Logger,
 		}
 
-		if config.TrustedAdvisorEnabled {
-			config.Logger.Log("level", "debug", "message", "trusted advisor collector is enabled")
-			c.Collectors = append(c.Collectors, trustedAdvisorCollector)
+		for _, s := range snapshots {
+			c.Collectors = append(c.Collectors, s)
 		}
 
 		collectorSet, err = collector.NewSet(c)
@@ -221,7 +459,76 @@ func NewSet(config SetConfig) (*Set, error) {
 
 	s := &Set{
 		Set: collectorSet,
+
+		snapshots: snapshots,
 	}
 
 	return s, nil
 }
+
+// Boot starts the background collection of all collectors, if enabled, and
+// registers the collector set.
+func (s *Set) Boot(ctx context.Context) error {
+	for _, item := range s.snapshots {
+		go item.Boot(ctx)
+	}
+
+	err := s.Set.Boot(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// collectorInterval returns the interval in which the given collector
+// refreshes its snapshot. Some collectors gather information changing only
+// slowly, which is why they are refreshed less often than the others.
+func collectorInterval(name string, background bool, interval time.Duration) time.Duration {
+	minimum := minCollectorIntervals[name]
+
+	if !background {
+		return minimum
+	}
+	if interval < minimum {
+		return minimum
+	}
+
+	return interval
+}
+
+// collectorConfig returns the configuration of the given collector, falling
+// back to the defaults in case it is not configured.
+func collectorConfig(name string, configs map[string]CollectorConfig) CollectorConfig {
+	c, ok := configs[name]
+	if !ok {
+		return CollectorConfig{Enabled: !disabledCollectors[name]}
+	}
+
+	return c
+}
+
+// validateCollectorConfigs ensures that only known collectors are configured
+// and that their configuration is sane.
+func validateCollectorConfigs(named []namedCollector, configs map[string]CollectorConfig) error {
+	known := map[string]bool{}
+	var names []string
+	for _, n := range named {
+		known[n.name] = true
+		names = append(names, n.name)
+	}
+
+	for name, c := range configs {
+		if !known[name] {
+			return microerror.Maskf(invalidConfigError, "unknown collector %#q, must be one of %s", name, strings.Join(names, ", "))
+		}
+		if c.Interval < 0 {
+			return microerror.Maskf(invalidConfigError, "interval of collector %#q must not be negative", name)
+		}
+		if c.Timeout < 0 {
+			return microerror.Maskf(invalidConfigError, "timeout of collector %#q must not be negative", name)
+		}
+	}
+
+	return nil
+}
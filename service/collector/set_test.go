@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestValidateCollectorConfigs(t *testing.T) {
+	named := []namedCollector{
+		{name: collectorNAT},
+		{name: collectorVPC},
+	}
+
+	testCases := []struct {
+		name    string
+		configs map[string]CollectorConfig
+
+		expectedError bool
+	}{
+		{
+			name: "case 0: known collectors",
+			configs: map[string]CollectorConfig{
+				collectorNAT: {Enabled: false},
+				collectorVPC: {Enabled: true, Interval: time.Minute, Timeout: time.Minute},
+			},
+
+			expectedError: false,
+		},
+		{
+			name: "case 1: unknown collector",
+			configs: map[string]CollectorConfig{
+				"natgateway": {Enabled: true},
+			},
+
+			expectedError: true,
+		},
+		{
+			name: "case 2: negative timeout",
+			configs: map[string]CollectorConfig{
+				collectorVPC: {Enabled: true, Timeout: -time.Minute},
+			},
+
+			expectedError: true,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := validateCollectorConfigs(named, tc.configs)
+
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("expected error to be %v, got %v", tc.expectedError, err)
+			}
+			if err != nil && !IsInvalidConfig(err) {
+				t.Fatalf("expected invalid config error, got %#v", err)
+			}
+		})
+	}
+}
+
+func TestCollectorInterval(t *testing.T) {
+	testCases := []struct {
+		name       string
+		collector  string
+		background bool
+		interval   time.Duration
+
+		expectedInterval time.Duration
+	}{
+		{
+			name:      "case 0: foreground without minimum",
+			collector: collectorVPC,
+			interval:  time.Minute,
+
+			expectedInterval: 0,
+		},
+		{
+			name:       "case 1: background without minimum",
+			collector:  collectorVPC,
+			background: true,
+			interval:   time.Minute,
+
+			expectedInterval: time.Minute,
+		},
+		{
+			name:       "case 2: background below minimum",
+			collector:  collectorNAT,
+			background: true,
+			interval:   time.Minute,
+
+			expectedInterval: 30 * time.Minute,
+		},
+		{
+			name:      "case 3: foreground with minimum",
+			collector: collectorELB,
+
+			expectedInterval: 5 * time.Minute,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			interval := collectorInterval(tc.collector, tc.background, tc.interval)
+
+			if interval != tc.expectedInterval {
+				t.Fatalf("expected %v, got %v", tc.expectedInterval, interval)
+			}
+		})
+	}
+}
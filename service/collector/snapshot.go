@@ -0,0 +1,236 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	collectorLastSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemCollector, "last_success_timestamp_seconds"),
+		"Unix timestamp of the last successful collection of a collector. 0 means there was no successful collection yet.",
+		[]string{
+			labelCollector,
+		},
+		nil,
+	)
+)
+
+// contextCollector is a collector whose collection is bound to the given
+// context, so that the snapshot can cancel collections exceeding their
+// timeout.
+type contextCollector interface {
+	Collect(ctx context.Context, ch chan<- prometheus.Metric) error
+	Describe(ch chan<- *prometheus.Desc) error
+}
+
+type snapshotConfig struct {
+	Collector contextCollector
+	Logger    micrologger.Logger
+
+	// Background defines whether the collector is executed in the background
+	// on every interval, or lazily within Prometheus scrapes once the last
+	// snapshot is older than the interval.
+	Background bool
+	Interval   time.Duration
+	Name       string
+	// Timeout limits the duration of a single collection. Zero means no
+	// timeout.
+	Timeout time.Duration
+}
+
+// snapshot wraps a collector and keeps the metrics of its last complete
+// collection. Scrapes are served from this snapshot, which decouples the
+// duration of Prometheus scrapes from the duration of AWS API calls and
+// replaces ad-hoc caching within the collectors themselves.
+type snapshot struct {
+	collector contextCollector
+	logger    micrologger.Logger
+
+	background bool
+	interval   time.Duration
+	name       string
+	timeout    time.Duration
+
+	collectedAt  time.Time
+	lastSuccess  time.Time
+	metrics      []prometheus.Metric
+	mutex        sync.RWMutex
+	refreshMutex sync.Mutex
+}
+
+func newSnapshot(config snapshotConfig) (*snapshot, error) {
+	if config.Collector == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Collector must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.Background && config.Interval <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Interval must be positive in background mode", config)
+	}
+	if config.Name == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Name must not be empty", config)
+	}
+
+	s := &snapshot{
+		collector: config.Collector,
+		logger:    config.Logger,
+
+		background: config.Background,
+		interval:   config.Interval,
+		name:       config.Name,
+		timeout:    config.Timeout,
+	}
+
+	return s, nil
+}
+
+// Boot starts collecting in the background in case background mode is
+// enabled. The first collection is done immediately. Boot returns once the
+// given context is done.
+func (s *snapshot) Boot(ctx context.Context) {
+	if !s.background {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.refresh()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect emits the metrics of the last complete collection. In foreground
+// mode the snapshot is refreshed first in case it is older than the interval.
+func (s *snapshot) Collect(ch chan<- prometheus.Metric) error {
+	if !s.background && s.isStale() {
+		s.refresh()
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, m := range s.metrics {
+		ch <- m
+	}
+
+	var lastSuccess float64
+	if !s.lastSuccess.IsZero() {
+		lastSuccess = float64(s.lastSuccess.Unix())
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		collectorLastSuccessDesc,
+		prometheus.GaugeValue,
+		lastSuccess,
+		s.name,
+	)
+
+	return nil
+}
+
+// Describe emits the description for the metrics collected here.
+func (s *snapshot) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- collectorLastSuccessDesc
+
+	err := s.collector.Describe(ch)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (s *snapshot) isStale() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return time.Since(s.collectedAt) >= s.interval
+}
+
+// refresh executes the underlying collector and replaces the current snapshot
+// in case the collection succeeded. On failure the previous snapshot is kept,
+// so consumers can tell about its age by the last success timestamp.
+func (s *snapshot) refresh() {
+	s.refreshMutex.Lock()
+	defer s.refreshMutex.Unlock()
+
+	// Concurrent scrapes in foreground mode wait for the refresh above, so
+	// there is no need to collect again once it finished.
+	if !s.background && !s.isStale() {
+		return
+	}
+
+	start := time.Now()
+	metrics, err := s.collect()
+	collectorDuration.WithLabelValues(s.name).Observe(time.Since(start).Seconds())
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.collectedAt = time.Now()
+
+	if err != nil {
+		collectorRuns.WithLabelValues(s.name, resultFailure).Inc()
+		s.logger.Log("level", "error", "message", fmt.Sprintf("failed collecting %s metrics", s.name), "stack", fmt.Sprintf("%#v", err))
+		return
+	}
+
+	collectorRuns.WithLabelValues(s.name, resultSuccess).Inc()
+	collectorSeries.WithLabelValues(s.name).Set(float64(len(metrics)))
+
+	s.lastSuccess = s.collectedAt
+	s.metrics = metrics
+}
+
+// collect executes the underlying collector and buffers all metrics it emits.
+// In case the collection exceeds the timeout, it is cancelled and its result
+// is discarded.
+func (s *snapshot) collect() ([]prometheus.Metric, error) {
+	ctx := context.Background()
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	ch := make(chan prometheus.Metric)
+	done := make(chan []prometheus.Metric)
+
+	go func() {
+		var metrics []prometheus.Metric
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+
+		done <- metrics
+	}()
+
+	err := s.collector.Collect(ctx, ch)
+	close(ch)
+	metrics := <-done
+
+	if ctx.Err() != nil {
+		return nil, microerror.Maskf(timeoutError, "collection did not finish within %s", s.timeout)
+	}
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return metrics, nil
+}
@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var testDesc = prometheus.NewDesc("test_metric", "Test metric.", nil, nil)
+
+type testCollector struct {
+	// block makes the collection wait until its context is done.
+	block bool
+	calls int
+	err   error
+}
+
+func (c *testCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	c.calls++
+	ch <- prometheus.MustNewConstMetric(testDesc, prometheus.GaugeValue, float64(c.calls))
+
+	if c.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	return c.err
+}
+
+func (c *testCollector) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- testDesc
+	return nil
+}
+
+func TestSnapshotForeground(t *testing.T) {
+	c := &testCollector{}
+
+	s, err := newSnapshot(snapshotConfig{
+		Collector: c,
+		Logger:    microloggertest.New(),
+
+		Interval: time.Hour,
+		Name:     "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first scrape collects, the second one is served from the snapshot.
+	for i := 0; i < 2; i++ {
+		metrics := collectSnapshot(t, s)
+		if len(metrics) != 2 {
+			t.Fatalf("expected 2 metrics, got %d", len(metrics))
+		}
+	}
+	if c.calls != 1 {
+		t.Fatalf("expected 1 collection, got %d", c.calls)
+	}
+
+	// A failing collection keeps the previous snapshot.
+	c.err = errors.New("test error")
+	s.collectedAt = time.Time{}
+	lastSuccess := s.lastSuccess
+
+	metrics := collectSnapshot(t, s)
+	if len(metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %d", len(metrics))
+	}
+	if c.calls != 2 {
+		t.Fatalf("expected 2 collections, got %d", c.calls)
+	}
+	if s.lastSuccess != lastSuccess {
+		t.Fatalf("expected last success to stay %v, got %v", lastSuccess, s.lastSuccess)
+	}
+}
+
+func TestSnapshotTimeout(t *testing.T) {
+	c := &testCollector{block: true}
+
+	s, err := newSnapshot(snapshotConfig{
+		Collector: c,
+		Logger:    microloggertest.New(),
+
+		Interval: time.Hour,
+		Name:     "test",
+		Timeout:  10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A collection exceeding the timeout is cancelled and its metrics are
+	// discarded.
+	metrics := collectSnapshot(t, s)
+	if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %d", len(metrics))
+	}
+	if !s.lastSuccess.IsZero() {
+		t.Fatalf("expected no last success, got %v", s.lastSuccess)
+	}
+
+	// The cancelled collection does not prevent the next one.
+	c.block = false
+	s.collectedAt = time.Time{}
+
+	metrics = collectSnapshot(t, s)
+	if len(metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %d", len(metrics))
+	}
+	if c.calls != 2 {
+		t.Fatalf("expected 2 collections, got %d", c.calls)
+	}
+}
+
+func collectSnapshot(t *testing.T, s *snapshot) []prometheus.Metric {
+	ch := make(chan prometheus.Metric, 10)
+
+	err := s.Collect(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+
+	var metrics []prometheus.Metric
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+
+	return metrics
+}
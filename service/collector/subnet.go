@@ -2,26 +2,17 @@ package collector
 
 import (
 	"context"
-	"encoding/json"
 	"math"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
-	"golang.org/x/sync/errgroup"
 
 	clientaws "github.com/giantswarm/aws-collector/client/aws"
 	"github.com/giantswarm/aws-collector/service/controller/key"
-	"github.com/giantswarm/aws-collector/service/internal/cache"
-)
-
-const (
-	// __SubnetCache__ is used as temporal cache key to save Subnet response.
-	prefixSubnetcacheKey = "__SubnetCache__"
 )
 
 const (
@@ -35,6 +26,7 @@ var (
 		"Number of still available IPs for each subnet.",
 		[]string{
 			labelAccountID,
+			labelRegion,
 			labelCIDR,
 			labelCluster,
 			labelID,
@@ -54,6 +46,7 @@ var (
 		"Percentage of still available IPs for each subnet.",
 		[]string{
 			labelAccountID,
+			labelRegion,
 			labelCIDR,
 			labelCluster,
 			labelID,
@@ -78,17 +71,12 @@ type SubnetConfig struct {
 }
 
 type Subnet struct {
-	cache  *subnetCache
 	helper *helper
 	logger micrologger.Logger
 
 	installationName string
 }
 
-type subnetCache struct {
-	cache *cache.StringCache
-}
-
 type subnetInfoResponse struct {
 	Subnets []subnetInfo
 }
@@ -113,7 +101,6 @@ func NewSubnet(config SubnetConfig) (*Subnet, error) {
 	}
 
 	e := &Subnet{
-		cache:  newSubnetCache(time.Minute * 5),
 		helper: config.Helper,
 		logger: config.Logger,
 
@@ -123,69 +110,15 @@ func NewSubnet(config SubnetConfig) (*Subnet, error) {
 	return e, nil
 }
 
-func newSubnetCache(expiration time.Duration) *subnetCache {
-	cache := &subnetCache{
-		cache: cache.NewStringCache(expiration),
-	}
-
-	return cache
-}
-
-func (n *subnetCache) Get(key string) (*subnetInfoResponse, error) {
-	var c subnetInfoResponse
-	raw, exists := n.cache.Get(getSubnetCacheKey(key))
-	if exists {
-		err := json.Unmarshal(raw, &c)
+func (e *Subnet) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	err := e.helper.ForEachAccount(ctx, collectorSubnet, func(acc account) error {
+		err := e.collectForAccount(ctx, ch, acc)
 		if err != nil {
-			return nil, microerror.Mask(err)
+			return microerror.Mask(err)
 		}
-	}
-
-	return &c, nil
-}
-
-func (n *subnetCache) Set(key string, content subnetInfoResponse) error {
-	contentSerialized, err := json.Marshal(content)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	n.cache.Set(getSubnetCacheKey(key), contentSerialized)
-
-	return nil
-}
-
-func getSubnetCacheKey(key string) string {
-	return prefixSubnetcacheKey + key
-}
 
-func (e *Subnet) Collect(ch chan<- prometheus.Metric) error {
-	reconciledClusters, err := e.helper.ListReconciledClusters()
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	awsClientsList, err := e.helper.GetAWSClients(context.Background(), reconciledClusters)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	var g errgroup.Group
-
-	for _, item := range awsClientsList {
-		awsClients := item
-
-		g.Go(func() error {
-			err := e.collectForAccount(context.Background(), ch, awsClients)
-			if err != nil {
-				return microerror.Mask(err)
-			}
-
-			return nil
-		})
-	}
-
-	err = g.Wait()
+		return nil
+	})
 	if err != nil {
 		return microerror.Mask(err)
 	}
@@ -199,41 +132,20 @@ func (e *Subnet) Describe(ch chan<- *prometheus.Desc) error {
 	return nil
 }
 
-func (e *Subnet) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, awsClients clientaws.Clients) error {
-	account, err := e.helper.AWSAccountID(awsClients)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	var subnetInfo *subnetInfoResponse
-	// Check if response is cached
-	subnetInfo, err = e.cache.Get(account)
+func (e *Subnet) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+	subnetInfo, err := e.getSubnetInfoFromAPI(ctx, acc.Clients)
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	//Cache empty, getting from API
-	if subnetInfo == nil || subnetInfo.Subnets == nil {
-		subnetInfo, err = e.getSubnetInfoFromAPI(ctx, awsClients)
-		if err != nil {
-			return microerror.Mask(err)
-		}
-
-		if subnetInfo != nil {
-			err = e.cache.Set(account, *subnetInfo)
-			if err != nil {
-				return microerror.Mask(err)
-			}
-		}
-	}
-
 	if subnetInfo != nil {
 		for _, subnet := range subnetInfo.Subnets {
 			ch <- prometheus.MustNewConstMetric(
 				subnetsDesc,
 				prometheus.GaugeValue,
 				float64(subnet.AvailableIPs),
-				account,
+				acc.ID,
+				acc.Region,
 				subnet.Tags["CidrBlock"],
 				subnet.Tags[key.TagCluster],
 				subnet.Name,
@@ -251,7 +163,8 @@ func (e *Subnet) collectForAccount(ctx context.Context, ch chan<- prometheus.Met
 				subnetsPercentageDesc,
 				prometheus.GaugeValue,
 				subnet.AvailableIPPercentage,
-				account,
+				acc.ID,
+				acc.Region,
 				subnet.Tags["CidrBlock"],
 				subnet.Tags[key.TagCluster],
 				subnet.Name,
@@ -273,13 +186,17 @@ func (e *Subnet) collectForAccount(ctx context.Context, ch chan<- prometheus.Met
 // getSubnetInfoFromAPI collects Subnet Info from AWS API
 func (e *Subnet) getSubnetInfoFromAPI(ctx context.Context, awsClients clientaws.Clients) (*subnetInfoResponse, error) {
 	var res subnetInfoResponse
-	o, err := awsClients.EC2.DescribeSubnets(&ec2.DescribeSubnetsInput{})
+	var list []*ec2.Subnet
+	err := awsClients.EC2.DescribeSubnetsPagesWithContext(ctx, &ec2.DescribeSubnetsInput{}, func(o *ec2.DescribeSubnetsOutput, lastPage bool) bool {
+		list = append(list, o.Subnets...)
+		return true
+	})
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
 
 	var subnets []subnetInfo
-	for _, sn := range o.Subnets {
+	for _, sn := range list {
 		subnet := subnetInfo{
 			Name:         *sn.SubnetId,
 			AvailableIPs: *sn.AvailableIpAddressCount,
@@ -33,7 +33,6 @@ const (
 )
 
 const (
-	labelRegion  = "region"
 	labelService = "service"
 )
 
@@ -98,33 +97,16 @@ func NewTrustedAdvisor(config TrustedAdvisorConfig) (*TrustedAdvisor, error) {
 	return t, nil
 }
 
-func (t *TrustedAdvisor) Collect(ch chan<- prometheus.Metric) error {
-	reconciledClusters, err := t.helper.ListReconciledClusters()
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	awsClientsList, err := t.helper.GetAWSClients(context.Background(), reconciledClusters)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	var g errgroup.Group
-
-	for _, item := range awsClientsList {
-		awsClients := item
-
-		g.Go(func() error {
-			err := t.collectForAccount(ch, awsClients)
-			if err != nil {
-				return microerror.Mask(err)
-			}
-
-			return nil
-		})
-	}
+func (t *TrustedAdvisor) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	// Trusted Advisor reports about all regions of an account at once.
+	err := t.helper.ForEachGlobalAccount(ctx, collectorTrustedAdvisor, func(acc account) error {
+		err := t.collectForAccount(ctx, ch, acc)
+		if err != nil {
+			return microerror.Mask(err)
+		}
 
-	err = g.Wait()
+		return nil
+	})
 	if err != nil {
 		return microerror.Mask(err)
 	}
@@ -138,13 +120,8 @@ func (t *TrustedAdvisor) Describe(ch chan<- *prometheus.Desc) error {
 	return nil
 }
 
-func (t *TrustedAdvisor) collectForAccount(ch chan<- prometheus.Metric, awsClients aws.Clients) error {
-	accountID, err := t.helper.AWSAccountID(awsClients)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	checks, err := t.getTrustedAdvisorChecks(awsClients)
+func (t *TrustedAdvisor) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+	checks, err := t.getTrustedAdvisorChecks(ctx, acc.Clients)
 	if IsUnsupportedPlan(err) {
 		// While iterating through all kinds of account related AWS clients, we may
 		// or may not be able to work against the Trusted Advisor API, depending on
@@ -167,7 +144,7 @@ func (t *TrustedAdvisor) collectForAccount(ch chan<- prometheus.Metric, awsClien
 		id := check.Id
 
 		g.Go(func() error {
-			resources, err := t.getTrustedAdvisorResources(id, awsClients)
+			resources, err := t.getTrustedAdvisorResources(ctx, id, acc.Clients)
 			if err != nil {
 				return microerror.Mask(err)
 			}
@@ -179,7 +156,7 @@ func (t *TrustedAdvisor) collectForAccount(ch chan<- prometheus.Metric, awsClien
 					continue
 				}
 
-				limit, usage, err := resourceToMetrics(resource, accountID)
+				limit, usage, err := resourceToMetrics(resource, acc.ID)
 				if err != nil {
 					return microerror.Mask(err)
 				}
@@ -202,14 +179,14 @@ func (t *TrustedAdvisor) collectForAccount(ch chan<- prometheus.Metric, awsClien
 
 // getTrustedAdvisorCheckDescriptions calls Trusted Advisor API to get all
 // available checks.
-func (t *TrustedAdvisor) getTrustedAdvisorChecks(awsClients aws.Clients) ([]*support.TrustedAdvisorCheckDescription, error) {
+func (t *TrustedAdvisor) getTrustedAdvisorChecks(ctx context.Context, awsClients aws.Clients) ([]*support.TrustedAdvisorCheckDescription, error) {
 	timer := prometheus.NewTimer(getChecksDuration)
 
 	englishLanguage := "en"
 	describeChecksInput := &support.DescribeTrustedAdvisorChecksInput{
 		Language: &englishLanguage,
 	}
-	describeChecksOutput, err := awsClients.Support.DescribeTrustedAdvisorChecks(describeChecksInput)
+	describeChecksOutput, err := awsClients.Support.DescribeTrustedAdvisorChecksWithContext(ctx, describeChecksInput)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
@@ -221,13 +198,13 @@ func (t *TrustedAdvisor) getTrustedAdvisorChecks(awsClients aws.Clients) ([]*sup
 
 // getTrustedAdvisorResources calls Trusted Advisor API to get flagged resources
 // of the given check ID.
-func (t *TrustedAdvisor) getTrustedAdvisorResources(id *string, awsClients aws.Clients) ([]*support.TrustedAdvisorResourceDetail, error) {
+func (t *TrustedAdvisor) getTrustedAdvisorResources(ctx context.Context, id *string, awsClients aws.Clients) ([]*support.TrustedAdvisorResourceDetail, error) {
 	timer := prometheus.NewTimer(getResourcesDuration)
 
 	checkResultInput := &support.DescribeTrustedAdvisorCheckResultInput{
 		CheckId: id,
 	}
-	checkResultOutput, err := awsClients.Support.DescribeTrustedAdvisorCheckResult(checkResultInput)
+	checkResultOutput, err := awsClients.Support.DescribeTrustedAdvisorCheckResultWithContext(ctx, checkResultInput)
 	if err != nil {
 		return nil, microerror.Mask(err)
 	}
@@ -91,9 +91,7 @@ func NewUpdate(config UpdateConfig) (*Update, error) {
 	return np, nil
 }
 
-func (np *Update) Collect(ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
-
+func (np *Update) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
 	var list infrastructurev1alpha3.AWSMachineDeploymentList
 	{
 		err := np.helper.clients.CtrlClient().List(
@@ -107,7 +105,7 @@ func (np *Update) Collect(ch chan<- prometheus.Metric) error {
 	var nodePools []updateInfo
 	{
 		for _, md := range list.Items {
-			batch, pause, err := np.getUpdateAnnotations(md)
+			batch, pause, err := np.getUpdateAnnotations(ctx, md)
 			if err != nil {
 				return microerror.Mask(err)
 			}
@@ -163,7 +161,7 @@ func (np *Update) Describe(ch chan<- *prometheus.Desc) error {
 	return nil
 }
 
-func (np *Update) getUpdateAnnotations(md infrastructurev1alpha3.AWSMachineDeployment) (string, string, error) {
+func (np *Update) getUpdateAnnotations(ctx context.Context, md infrastructurev1alpha3.AWSMachineDeployment) (string, string, error) {
 	var batch, time string
 
 	// get info from AWSMachineDeployment
@@ -180,7 +178,7 @@ func (np *Update) getUpdateAnnotations(md infrastructurev1alpha3.AWSMachineDeplo
 	{
 		var clusterList infrastructurev1alpha3.AWSClusterList
 		err := np.helper.clients.CtrlClient().List(
-			context.Background(),
+			ctx,
 			&clusterList,
 			client.MatchingLabels{label.Cluster: md.Labels[label.Cluster]},
 		)
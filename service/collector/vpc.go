@@ -7,9 +7,7 @@ import (
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/prometheus/client_golang/prometheus"
-	"golang.org/x/sync/errgroup"
 
-	clientaws "github.com/giantswarm/aws-collector/client/aws"
 	"github.com/giantswarm/aws-collector/service/controller/key"
 )
 
@@ -30,6 +28,7 @@ var (
 		"VPC information.",
 		[]string{
 			labelAccountID,
+			labelRegion,
 			labelCIDR,
 			labelCluster,
 			labelID,
@@ -79,33 +78,15 @@ func NewVPC(config VPCConfig) (*VPC, error) {
 	return v, nil
 }
 
-func (v *VPC) Collect(ch chan<- prometheus.Metric) error {
-	reconciledClusters, err := v.helper.ListReconciledClusters()
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	awsClientsList, err := v.helper.GetAWSClients(context.Background(), reconciledClusters)
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	var g errgroup.Group
-
-	for _, item := range awsClientsList {
-		awsClients := item
-
-		g.Go(func() error {
-			err := v.collectForAccount(ch, awsClients)
-			if err != nil {
-				return microerror.Mask(err)
-			}
-
-			return nil
-		})
-	}
+func (v *VPC) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	err := v.helper.ForEachAccount(ctx, collectorVPC, func(acc account) error {
+		err := v.collectForAccount(ctx, ch, acc)
+		if err != nil {
+			return microerror.Mask(err)
+		}
 
-	err = g.Wait()
+		return nil
+	})
 	if err != nil {
 		return microerror.Mask(err)
 	}
@@ -118,18 +99,17 @@ func (v *VPC) Describe(ch chan<- *prometheus.Desc) error {
 	return nil
 }
 
-func (v *VPC) collectForAccount(ch chan<- prometheus.Metric, awsClients clientaws.Clients) error {
-	o, err := awsClients.EC2.DescribeVpcs(&ec2.DescribeVpcsInput{})
-	if err != nil {
-		return microerror.Mask(err)
-	}
-
-	accountID, err := v.helper.AWSAccountID(awsClients)
+func (v *VPC) collectForAccount(ctx context.Context, ch chan<- prometheus.Metric, acc account) error {
+	var vpcs []*ec2.Vpc
+	err := acc.Clients.EC2.DescribeVpcsPagesWithContext(ctx, &ec2.DescribeVpcsInput{}, func(o *ec2.DescribeVpcsOutput, lastPage bool) bool {
+		vpcs = append(vpcs, o.Vpcs...)
+		return true
+	})
 	if err != nil {
 		return microerror.Mask(err)
 	}
 
-	for _, vpc := range o.Vpcs {
+	for _, vpc := range vpcs {
 		var cluster, installation, name, organization, stackName string
 
 		for _, tag := range vpc.Tags {
@@ -155,7 +135,8 @@ func (v *VPC) collectForAccount(ch chan<- prometheus.Metric, awsClients clientaw
 			vpcsDesc,
 			prometheus.GaugeValue,
 			GaugeValue,
-			accountID,
+			acc.ID,
+			acc.Region,
 			*vpc.CidrBlock,
 			cluster,
 			*vpc.VpcId,
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/viper"
+
+	"github.com/giantswarm/aws-collector/flag"
+	"github.com/giantswarm/aws-collector/service/collector"
+)
+
+const (
+	// configDirsKey and configFilesKey are the keys of the microkit daemon
+	// flags defining the config files in use.
+	configDirsKey  = "config.dirs"
+	configFilesKey = "config.files"
+)
+
+// newCollectorConfigs creates the configuration of every collector based on
+// the service.collectors flags.
+func newCollectorConfigs(f *flag.Flag, v *viper.Viper) (map[string]collector.CollectorConfig, error) {
+	configs := map[string]collector.CollectorConfig{}
+
+	for name, c := range f.Service.Collectors.All() {
+		configs[name] = collector.CollectorConfig{
+			Enabled:  v.GetBool(c.Enabled),
+			Interval: v.GetDuration(c.Interval),
+			Timeout:  v.GetDuration(c.Timeout),
+		}
+	}
+
+	// Trusted Advisor used to be enabled via its own flag, which is still
+	// respected for backward compatibility.
+	if v.GetBool(f.Service.AWS.TrustedAdvisor.Enabled) {
+		c := configs["trustedadvisor"]
+		c.Enabled = true
+		configs["trustedadvisor"] = c
+	}
+
+	// Config file values are only merged into the given viper for known
+	// flags, so collectors unknown to the flag package would be ignored
+	// silently. They are added here in order to fail validation instead.
+	names, err := configuredCollectorNames(v.GetStringSlice(configDirsKey), v.GetStringSlice(configFilesKey))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	for _, name := range names {
+		_, ok := configs[name]
+		if !ok {
+			configs[name] = collector.CollectorConfig{}
+		}
+	}
+
+	return configs, nil
+}
+
+// configuredCollectorNames returns the names of all collectors found in the
+// service.collectors block of the given config files.
+func configuredCollectorNames(dirs []string, files []string) ([]string, error) {
+	var names []string
+
+	for _, f := range files {
+		v := viper.New()
+		for _, d := range dirs {
+			v.AddConfigPath(d)
+		}
+		v.SetConfigName(f)
+
+		err := v.ReadInConfig()
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			continue
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for name := range v.GetStringMap("service.collectors") {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestConfiguredCollectorNames(t *testing.T) {
+	dir := t.TempDir()
+
+	config := `
+service:
+  collectors:
+    nat:
+      enabled: false
+    natgateway:
+      interval: 10m
+`
+	err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := configuredCollectorNames([]string{dir}, []string{"config", "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+
+	if len(names) != 2 || names[0] != "nat" || names[1] != "natgateway" {
+		t.Fatalf("expected [nat natgateway], got %v", names)
+	}
+}
@@ -84,3 +84,7 @@ func CredentialName(cluster infrastructurev1alpha3.AWSCluster) string {
 func CredentialNamespace(cluster infrastructurev1alpha3.AWSCluster) string {
 	return cluster.Spec.Provider.CredentialSecret.Namespace
 }
+
+func Region(cluster infrastructurev1alpha3.AWSCluster) string {
+	return cluster.Spec.Provider.Region
+}
@@ -1,6 +1,7 @@
 package accountid
 
 import (
+	"context"
 	"regexp"
 	"strings"
 	"sync"
@@ -51,7 +52,7 @@ func New(config Config) (*AccountID, error) {
 	return a, nil
 }
 
-func (a *AccountID) Lookup() (string, error) {
+func (a *AccountID) Lookup(ctx context.Context) (string, error) {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
@@ -59,7 +60,7 @@ func (a *AccountID) Lookup() (string, error) {
 		return a.accountID, nil
 	}
 
-	accountID, err := a.lookup()
+	accountID, err := a.lookup(ctx)
 	if err != nil {
 		return "", microerror.Mask(err)
 	}
@@ -68,12 +69,12 @@ func (a *AccountID) Lookup() (string, error) {
 	return accountID, nil
 }
 
-func (a *AccountID) lookup() (string, error) {
+func (a *AccountID) lookup(ctx context.Context) (string, error) {
 	var arn string
 	{
 		i := &sts.GetCallerIdentityInput{}
 
-		o, err := a.sts.GetCallerIdentity(i)
+		o, err := a.sts.GetCallerIdentityWithContext(ctx, i)
 		if err != nil {
 			return "", microerror.Mask(err)
 		}
@@ -1,13 +1,16 @@
 package accountid
 
 import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/sts"
 )
 
 type Interface interface {
-	Lookup() (string, error)
+	Lookup(ctx context.Context) (string, error)
 }
 
 type STS interface {
-	GetCallerIdentity(*sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error)
+	GetCallerIdentityWithContext(context.Context, *sts.GetCallerIdentityInput, ...request.Option) (*sts.GetCallerIdentityOutput, error)
 }
@@ -46,3 +46,22 @@ func Test_floatCache_NotExist(t *testing.T) {
 		t.Fatalf("not existed key must return false")
 	}
 }
+
+func Test_Cache_Set(t *testing.T) {
+	c := NewCache[[]string](time.Minute * 1)
+
+	_, ok := c.Get("key")
+	if ok {
+		t.Fatalf("cache key must not exist")
+	}
+
+	c.Set("key", []string{"a", "b"})
+
+	value, ok := c.Get("key")
+	if !ok {
+		t.Fatalf("cache key must exist")
+	}
+	if len(value) != 2 || value[0] != "a" || value[1] != "b" {
+		t.Fatalf("cache value == %v, want %v", value, []string{"a", "b"})
+	}
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"time"
+
+	gocache "github.com/patrickmn/go-cache"
+)
+
+// Cache is a cache of values of type V keyed by string.
+type Cache[V any] struct {
+	underlying *gocache.Cache
+}
+
+func NewCache[V any](expiration time.Duration) *Cache[V] {
+	c := &Cache[V]{
+		// Clean up period is set to half of the expiration, which means values are
+		// checked to be cleaned at least once before the expiration time.
+		underlying: gocache.New(expiration, expiration/2),
+	}
+
+	return c
+}
+
+func (c *Cache[V]) Get(k string) (V, bool) {
+	var zero V
+
+	v, exists := c.underlying.Get(k)
+	if !exists {
+		return zero, false
+	}
+
+	vv, ok := v.(V)
+	if !ok {
+		return zero, false
+	}
+
+	return vv, true
+}
+
+func (c *Cache[V]) Set(k string, v V) {
+	c.underlying.Set(k, v, 0)
+}
+
+func (c *Cache[V]) Delete(k string) {
+	c.underlying.Delete(k)
+}
@@ -94,10 +94,23 @@ func New(config Config) (*Service, error) {
 	var awsConfig aws.Config
 	{
 		awsConfig = aws.Config{
-			AccessKeyID:     config.Viper.GetString(config.Flag.Service.AWS.HostAccessKey.ID),
-			AccessKeySecret: config.Viper.GetString(config.Flag.Service.AWS.HostAccessKey.Secret),
-			Region:          config.Viper.GetString(config.Flag.Service.AWS.Region),
-			SessionToken:    config.Viper.GetString(config.Flag.Service.AWS.HostAccessKey.Session),
+			AccessKeyID:      config.Viper.GetString(config.Flag.Service.AWS.HostAccessKey.ID),
+			AccessKeySecret:  config.Viper.GetString(config.Flag.Service.AWS.HostAccessKey.Secret),
+			CredentialSource: config.Viper.GetString(config.Flag.Service.AWS.CredentialSource),
+			Region:           config.Viper.GetString(config.Flag.Service.AWS.Region),
+			SessionToken:     config.Viper.GetString(config.Flag.Service.AWS.HostAccessKey.Session),
+
+			MaxRetries:     config.Viper.GetInt(config.Flag.Service.AWS.MaxRetries),
+			RateLimit:      config.Viper.GetFloat64(config.Flag.Service.AWS.RateLimit.RequestsPerSecond),
+			RateLimitBurst: config.Viper.GetInt(config.Flag.Service.AWS.RateLimit.Burst),
+		}
+	}
+
+	var collectorConfigs map[string]collector.CollectorConfig
+	{
+		collectorConfigs, err = newCollectorConfigs(config.Flag, config.Viper)
+		if err != nil {
+			return nil, microerror.Mask(err)
 		}
 	}
 
@@ -107,9 +120,18 @@ func New(config Config) (*Service, error) {
 			Clients: k8sClient,
 			Logger:  config.Logger,
 
-			AWSConfig:             awsConfig,
-			InstallationName:      config.Viper.GetString(config.Flag.Service.Installation.Name),
-			TrustedAdvisorEnabled: config.Viper.GetBool(config.Flag.Service.AWS.TrustedAdvisor.Enabled),
+			AWSConfig:                   awsConfig,
+			Background:                  config.Viper.GetBool(config.Flag.Service.Collection.Background),
+			CAPADiscovery:               config.Viper.GetBool(config.Flag.Service.Discovery.CAPA.Enabled),
+			Collectors:                  collectorConfigs,
+			DeletedStacksGracePeriod:    config.Viper.GetDuration(config.Flag.Service.Collectors.CloudFormation.DeletedStacksGracePeriod),
+			DriftDetection:              config.Viper.GetBool(config.Flag.Service.Collectors.CloudFormation.DriftDetection.Enabled),
+			DriftDetectionInterval:      config.Viper.GetDuration(config.Flag.Service.Collectors.CloudFormation.DriftDetection.Interval),
+			GiantSwarmDiscovery:         config.Viper.GetBool(config.Flag.Service.Discovery.GiantSwarm.Enabled),
+			InstallationName:            config.Viper.GetString(config.Flag.Service.Installation.Name),
+			Interval:                    config.Viper.GetDuration(config.Flag.Service.Collection.Interval),
+			NATGatewayCloudWatchMetrics: config.Viper.GetBool(config.Flag.Service.Collectors.NATGateway.CloudWatchMetrics),
+			ServiceQuotas:               config.Viper.GetStringSlice(config.Flag.Service.Collectors.ServiceQuota.Quotas),
 		}
 
 		operatorCollector, err = collector.NewSet(c)